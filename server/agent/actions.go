@@ -3,6 +3,7 @@ package agent
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/anamika/zenact-web/server/browser"
@@ -19,19 +20,23 @@ func ExecuteAction(b *browser.Browser, resp *models.LLMResponse) error {
 		if err := b.Navigate(resp.Value); err != nil {
 			return fmt.Errorf("navigate to %s failed: %w", resp.Value, err)
 		}
-		// Wait for page to start loading
-		time.Sleep(2 * time.Second)
 		return nil
 
 	case models.ActionClick:
+		if resp.Element > 0 {
+			return b.ClickElement(resp.Element)
+		}
 		if resp.Selector == "" {
-			return fmt.Errorf("click action requires a selector")
+			return fmt.Errorf("click action requires an element or a selector")
 		}
 		return b.Click(resp.Selector)
 
 	case models.ActionTypeText:
+		if resp.Element > 0 {
+			return b.TypeElement(resp.Element, resp.Value)
+		}
 		if resp.Selector == "" {
-			return fmt.Errorf("type action requires a selector")
+			return fmt.Errorf("type action requires an element or a selector")
 		}
 		return b.Type(resp.Selector, resp.Value)
 
@@ -47,8 +52,8 @@ func ExecuteAction(b *browser.Browser, resp *models.LLMResponse) error {
 		return nil
 
 	case models.ActionHold:
-		if resp.Selector == "" {
-			return fmt.Errorf("hold action requires a selector")
+		if resp.Element == 0 && resp.Selector == "" {
+			return fmt.Errorf("hold action requires an element or a selector")
 		}
 		duration := 1000
 		if resp.Value != "" {
@@ -56,16 +61,104 @@ func ExecuteAction(b *browser.Browser, resp *models.LLMResponse) error {
 				duration = d
 			}
 		}
-		return b.Hold(resp.Selector, time.Duration(duration)*time.Millisecond)
+		return b.Hold(targetOf(resp), time.Duration(duration)*time.Millisecond)
 
 	case models.ActionDrag:
-		if resp.Selector == "" {
-			return fmt.Errorf("drag action requires a source selector")
+		if resp.Element == 0 && resp.Selector == "" {
+			return fmt.Errorf("drag action requires a source element or selector")
 		}
 		if resp.Value == "" {
 			return fmt.Errorf("drag action requires a target in value")
 		}
-		return b.Drag(resp.Selector, resp.Value)
+		return b.Drag(targetOf(resp), resp.Value)
+
+	case models.ActionClickAt:
+		return b.ClickPoint(resp.X, resp.Y)
+
+	case models.ActionDoubleClickAt:
+		return b.DoubleClickPoint(resp.X, resp.Y)
+
+	case models.ActionMoveTo:
+		return b.MovePointer(resp.X, resp.Y)
+
+	case models.ActionDragTo:
+		return b.DragPoints(resp.X, resp.Y, resp.ToX, resp.ToY)
+
+	case models.ActionPress, models.ActionHotkey:
+		if resp.Value == "" {
+			return fmt.Errorf("%s action requires a key in value", resp.Action)
+		}
+		if resp.Element > 0 || resp.Selector != "" {
+			return b.HotkeyOn(targetOf(resp), resp.Value)
+		}
+		return b.Hotkey(resp.Value)
+
+	case models.ActionSelectOption:
+		if resp.Value == "" {
+			return fmt.Errorf("select_option action requires the option value or label in value")
+		}
+		return b.SelectOption(targetOf(resp), resp.Value)
+
+	case models.ActionCheck:
+		return b.SetChecked(targetOf(resp), true)
+
+	case models.ActionUncheck:
+		return b.SetChecked(targetOf(resp), false)
+
+	case models.ActionSetValue:
+		return b.SetValue(targetOf(resp), resp.Value)
+
+	case models.ActionUpload:
+		if resp.Value == "" {
+			return fmt.Errorf("upload action requires file names in value")
+		}
+		var names []string
+		for _, name := range strings.Split(resp.Value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return b.UploadFiles(targetOf(resp), names)
+
+	case models.ActionSwitchTab:
+		n, err := strconv.Atoi(strings.TrimSpace(resp.Value))
+		if err != nil {
+			return fmt.Errorf("switch_tab action requires a tab number in value")
+		}
+		return b.SwitchTab(n)
+
+	case models.ActionNewTab:
+		return b.NewTab(resp.Value)
+
+	case models.ActionCloseTab:
+		n := 0
+		if resp.Value != "" {
+			var err error
+			if n, err = strconv.Atoi(strings.TrimSpace(resp.Value)); err != nil {
+				return fmt.Errorf("close_tab action takes an optional tab number in value")
+			}
+		}
+		return b.CloseTab(n)
+
+	case models.ActionAcceptDialog:
+		return b.HandleDialog(true, resp.Value)
+
+	case models.ActionDismissDialog:
+		return b.HandleDialog(false, "")
+
+	case models.ActionSaveScreenshot:
+		kind := browser.CaptureViewport
+		if resp.Element > 0 || resp.Selector != "" {
+			kind = browser.CaptureElement
+		} else if strings.EqualFold(strings.TrimSpace(resp.Value), "full") {
+			kind = browser.CaptureFullPage
+		}
+		_, err := b.SaveCapture(kind, targetOf(resp), "screenshot.png")
+		return err
+
+	case models.ActionSavePDF:
+		_, err := b.SaveCapture(browser.CapturePDF, browser.Target{}, "page.pdf")
+		return err
 
 	case models.ActionDone:
 		return nil
@@ -74,3 +167,74 @@ func ExecuteAction(b *browser.Browser, resp *models.LLMResponse) error {
 		return fmt.Errorf("unknown action type: %s", resp.Action)
 	}
 }
+
+// executeUntilDialog runs an action but stops waiting for it when it opens a
+// dialog that is left for the agent: the dialog blocks the action's CDP calls
+// until it is handled, which only happens in a later iteration.
+func executeUntilDialog(b *browser.Browser, resp *models.LLMResponse) error {
+	// Drop a signal left over from a dialog that opened between actions
+	select {
+	case <-b.DialogOpened():
+	default:
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- ExecuteAction(b, resp) }()
+	select {
+	case err := <-done:
+		return err
+	case <-b.DialogOpened():
+		return nil
+	}
+}
+
+// describeDialogs reports the open dialog and those closed by the dialog
+// policy since the last observation.
+func describeDialogs(open *browser.Dialog, handled []browser.Dialog) string {
+	var sb strings.Builder
+	for _, d := range handled {
+		fmt.Fprintf(&sb, "A %s dialog %q was %s automatically.\n", d.Type, d.Message, d.Result)
+	}
+	if open != nil {
+		fmt.Fprintf(&sb, "A %s dialog is OPEN and blocks the page: %q", open.Type, open.Message)
+		if open.Type == "prompt" {
+			fmt.Fprintf(&sb, " (default answer %q)", open.DefaultPrompt)
+		}
+		sb.WriteString("\nHandle it with accept_dialog or dismiss_dialog before doing anything else.\n")
+	}
+	return sb.String()
+}
+
+// activeTabTitle reads the active tab's title from the tab list, which works
+// while the page is blocked.
+func activeTabTitle(b *browser.Browser) string {
+	for _, t := range b.Tabs() {
+		if t.Active {
+			return t.Title
+		}
+	}
+	return ""
+}
+
+// relevanceQuery is the text page structure is ranked against: the task and
+// what the model last said it was working towards.
+func relevanceQuery(prompt string, history []models.Step) string {
+	if len(history) == 0 {
+		return prompt
+	}
+	return prompt + "\n" + history[len(history)-1].Thought
+}
+
+// isCoordinateAction reports whether an action targets screenshot coordinates.
+func isCoordinateAction(t models.ActionType) bool {
+	switch t {
+	case models.ActionClickAt, models.ActionDoubleClickAt, models.ActionMoveTo, models.ActionDragTo:
+		return true
+	}
+	return false
+}
+
+// targetOf returns the element an action refers to, by number or selector.
+func targetOf(resp *models.LLMResponse) browser.Target {
+	return browser.Target{Element: resp.Element, Selector: resp.Selector}
+}
@@ -7,7 +7,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -20,15 +21,31 @@ import (
 
 const maxConsecutiveLLMErrors = 5
 
+var (
+	ErrTaskNotFound   = errors.New("task not found")
+	ErrTaskNotRunning = errors.New("task is not running")
+
+	ErrScreencastDisabled = errors.New("live view is disabled (SCREENCAST_FPS=0)")
+	ErrSelectorNotBlocked = errors.New("selector is not blocked")
+)
+
 type Agent struct {
 	cfg       *config.Config
 	llmClient *llm.Client
 
-	tasks map[string]*models.Task
-	mu    sync.RWMutex
+	tasks    map[string]*models.Task
+	browsers map[string]*browser.Browser // of running tasks
+	mu       sync.RWMutex
 
 	subscribers map[string][]chan models.WSEvent
 	subMu       sync.RWMutex
+
+	frameSubs  map[string][]*FrameSubscription
+	lastFrames map[string]models.ScreencastFrame
+	frameMu    sync.Mutex
+
+	blocklistOnce sync.Once
+	blocklist     *browser.HostList
 }
 
 func New(cfg *config.Config, llmClient *llm.Client) *Agent {
@@ -36,149 +53,73 @@ func New(cfg *config.Config, llmClient *llm.Client) *Agent {
 		cfg:         cfg,
 		llmClient:   llmClient,
 		tasks:       make(map[string]*models.Task),
+		browsers:    make(map[string]*browser.Browser),
 		subscribers: make(map[string][]chan models.WSEvent),
+		frameSubs:   make(map[string][]*FrameSubscription),
+		lastFrames:  make(map[string]models.ScreencastFrame),
 	}
 }
 
-// StartTask creates a new task and launches the agent loop.
-func (a *Agent) StartTask(prompt string) string {
-	taskID := uuid.New().String()
-	initialSummary := fmt.Sprintf("## Task Summary\n\n**Goal:** %s\n\n**Initial Context:**\n- Task just started\n- No pages visited yet\n- No actions taken\n\n**Progress:**\n- [ ] Started task\n", prompt)
-	task := &models.Task{
-		ID:        taskID,
-		Prompt:    prompt,
-		Status:    models.TaskStatusPending,
-		Steps:     []models.Step{},
-		Summary:   initialSummary,
-		CreatedAt: time.Now(),
-	}
-
-	a.mu.Lock()
-	a.tasks[taskID] = task
-	a.mu.Unlock()
-
-	go a.runLoop(taskID)
-	return taskID
-}
-
-// updateSummary updates the task summary with new information after each step.
-func (a *Agent) updateSummary(taskID string, iteration int, action models.Action, thought string, executionSuccess bool, executionError string, pageURL string) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	task, ok := a.tasks[taskID]
-	if !ok {
-		return
-	}
-
-	var summary strings.Builder
-	summary.WriteString(task.Summary)
-
-	// Check if this step already exists in summary
-	if strings.Contains(task.Summary, fmt.Sprintf("Step %d:", iteration)) {
-		return
+// StartTask creates a new task, stores its input files and launches the agent loop.
+func (a *Agent) StartTask(req models.CreateTaskRequest, uploads []Upload) (string, error) {
+	if _, err := browser.ParseDialogPolicy(req.DialogPolicy); err != nil {
+		return "", err
 	}
-
-	// Determine current phase based on iteration and content
-	phase := "Progress"
-	if strings.Contains(task.Summary, "Logged in") || strings.Contains(pageURL, "dashboard") || strings.Contains(pageURL, "account") {
-		phase = "Logged in / Post-login"
-	} else if strings.Contains(task.Summary, "Navigation") && !strings.Contains(task.Summary, "logged in") {
-		phase = "Navigation"
-	}
-
-	// Build progress indicator
-	progressIndicator := "[ ]"
-	if executionSuccess {
-		progressIndicator = "[x]"
+	if err := browser.ValidateDomainPatterns(append(req.AllowedDomains, req.DeniedDomains...)); err != nil {
+		return "", err
 	}
-
-	// Write step summary
-	summary.WriteString(fmt.Sprintf("\n### Step %d (%s)\n", iteration, phase))
-	summary.WriteString(fmt.Sprintf("- **Action:** %s", action.Type))
-
-	if action.Selector != "" {
-		summary.WriteString(fmt.Sprintf(" on `%s`", action.Selector))
+	if err := browser.ValidateResourceTypes(req.BlockResources); err != nil {
+		return "", err
 	}
-	if action.Value != "" {
-		if action.Type == models.ActionTypeText {
-			summary.WriteString(fmt.Sprintf(" with value: \"%s\"", truncate(action.Value, 50)))
-		} else {
-			summary.WriteString(fmt.Sprintf(" with value: %s", truncate(action.Value, 50)))
+	if req.Proxy != "" {
+		if _, _, err := browser.ParseProxyURL(req.Proxy); err != nil {
+			return "", err
 		}
 	}
-	summary.WriteString(fmt.Sprintf("\n"))
-
-	// Only include brief thought - not full context
-	if thought != "" {
-		summary.WriteString(fmt.Sprintf("- **Thought:** %s\n", truncate(thought, 150)))
-	}
-
-	if !executionSuccess && executionError != "" {
-		summary.WriteString(fmt.Sprintf("- **Error:** %s\n", truncate(executionError, 200)))
-		summary.WriteString(fmt.Sprintf("- **Status:** %s FAILED\n", progressIndicator))
-
-		// Add what NOT to repeat - explicit guidance
-		lessonsLearned := extractLessonsLearned(action, executionError)
-		if lessonsLearned != "" {
-			summary.WriteString(fmt.Sprintf("- **DO NOT REPEAT:** %s\n", lessonsLearned))
-		}
-	} else if executionSuccess {
-		summary.WriteString(fmt.Sprintf("- **Status:** %s Completed\n", progressIndicator))
-
-		// Extract and add key discoveries
-		keyDiscoveries := extractKeyDiscoveries(action, executionSuccess, executionError, pageURL)
-		if keyDiscoveries != "" {
-			summary.WriteString(fmt.Sprintf("- **Key Discovery:** %s\n", keyDiscoveries))
-		}
+	if req.BasicAuth != nil && req.BasicAuth.Username == "" {
+		return "", fmt.Errorf("basic_auth requires a username")
 	}
-
-	task.Summary = summary.String()
-}
-
-// extractLessonsLearned provides explicit guidance on what NOT to repeat
-func extractLessonsLearned(action models.Action, executionError string) string {
-	if strings.Contains(executionError, "element not found") || strings.Contains(executionError, "does not have child") {
-		if action.Type == models.ActionClick {
-			return fmt.Sprintf("Selector `%s` doesn't work - element not found or incorrect", truncate(action.Selector, 50))
-		}
-		if action.Type == models.ActionTypeText {
-			return "Textarea interaction failed - editor may use rich text or different DOM structure"
-		}
+	emu := a.emulation(&models.Task{Device: req.Device, Locale: req.Locale, Timezone: req.Timezone, Geolocation: req.Geolocation})
+	if err := emu.Validate(); err != nil {
+		return "", err
 	}
 
-	if strings.Contains(executionError, "not visible") {
-		return fmt.Sprintf("Element `%s` exists but is not visible - scroll or dismiss overlays first", truncate(action.Selector, 50))
-	}
+	taskID := uuid.New().String()
 
-	if strings.Contains(executionError, "click failed") {
-		return fmt.Sprintf("Click on `%s` failed - element may be covered or disabled", truncate(action.Selector, 50))
+	inputFiles, err := a.prepareInputs(taskID, req.Files, uploads)
+	if err != nil {
+		os.RemoveAll(a.taskDir(taskID))
+		return "", err
 	}
 
-	return ""
-}
-
-// extractKeyDiscoveries extracts important information from successful actions
-func extractKeyDiscoveries(action models.Action, success bool, executionError string, pageURL string) string {
-	if !success && strings.Contains(executionError, "element not found") {
-		if action.Type == models.ActionClick {
-			return fmt.Sprintf("Element selector '%s' was incorrect - need better selector or element may not exist", truncate(action.Selector, 50))
-		}
+	task := &models.Task{
+		ID:             taskID,
+		Prompt:         req.Prompt,
+		Status:         models.TaskStatusPending,
+		Steps:          []models.Step{},
+		InputFiles:     inputFiles,
+		DialogPolicy:   req.DialogPolicy,
+		CaptureBodies:  req.CaptureBodies,
+		AllowedDomains: req.AllowedDomains,
+		DeniedDomains:  req.DeniedDomains,
+		BlockResources: req.BlockResources,
+		UseBlocklist:   req.UseBlocklist,
+		Proxy:          req.Proxy,
+		ExtraHeaders:   req.ExtraHeaders,
+		BasicAuth:      req.BasicAuth,
+		Device:         req.Device,
+		Locale:         req.Locale,
+		Timezone:       req.Timezone,
+		Geolocation:    req.Geolocation,
+		CreatedAt:      time.Now(),
 	}
 
-	if success {
-		if action.Type == models.ActionTypeText {
-			return fmt.Sprintf("Successfully typed into %s", truncate(action.Selector, 50))
-		}
-		if action.Type == models.ActionClick {
-			return fmt.Sprintf("Successfully clicked %s", truncate(action.Selector, 50))
-		}
-		if action.Type == models.ActionNavigate {
-			return fmt.Sprintf("Navigated to %s", pageURL)
-		}
-	}
+	a.mu.Lock()
+	a.tasks[taskID] = task
+	a.mu.Unlock()
 
-	return ""
+	go a.runLoop(taskID)
+	return taskID, nil
 }
 
 // truncate truncates a string to the given length
@@ -200,6 +141,14 @@ func (a *Agent) GetTask(taskID string) (*models.Task, bool) {
 	copied := *task
 	copied.Steps = make([]models.Step, len(task.Steps))
 	copy(copied.Steps, task.Steps)
+	copied.Artifacts = append([]models.Artifact(nil), task.Artifacts...)
+	copied.BlockedSelectors = append([]models.BlockedSelector(nil), task.BlockedSelectors...)
+	if task.BlockedRequests != nil {
+		copied.BlockedRequests = make(map[string]int, len(task.BlockedRequests))
+		for key, n := range task.BlockedRequests {
+			copied.BlockedRequests[key] = n
+		}
+	}
 	return &copied, true
 }
 
@@ -226,15 +175,35 @@ func (a *Agent) Unsubscribe(taskID string, ch chan models.WSEvent) {
 	}
 }
 
-// broadcast sends an event to all subscribers of a task.
+// eventReserve is how many slots of a subscriber's buffer are kept for
+// lifecycle events: screenshots and console output only use the rest.
+const eventReserve = 20
+
+// broadcast sends an event to all subscribers of a task, dropping it for a
+// subscriber whose buffer is full. Screenshots and console output leave the
+// last eventReserve slots to step and task events, and never wait for the
+// subscriber list, as console output arrives on the browser's event
+// goroutine.
 func (a *Agent) broadcast(taskID string, event models.WSEvent) {
-	a.subMu.RLock()
+	lossy := event.Type == models.WSEventScreenshot || event.Type == models.WSEventConsole
+	if lossy {
+		if !a.subMu.TryRLock() {
+			log.Printf("WARNING: dropping %s event for task %s (subscribers changing)", event.Type, taskID)
+			return
+		}
+	} else {
+		a.subMu.RLock()
+	}
 	defer a.subMu.RUnlock()
 	for _, ch := range a.subscribers[taskID] {
+		if lossy && len(ch) >= cap(ch)-eventReserve {
+			log.Printf("WARNING: dropping %s event for task %s (slow consumer)", event.Type, taskID)
+			continue
+		}
 		select {
 		case ch <- event:
 		default:
-			log.Printf("WARNING: dropping event for task %s (slow consumer)", taskID)
+			log.Printf("WARNING: dropping %s event for task %s (slow consumer)", event.Type, taskID)
 		}
 	}
 }
@@ -247,29 +216,113 @@ func (a *Agent) runLoop(taskID string) {
 	a.mu.Unlock()
 
 	// Create browser
-	b, err := browser.New(a.cfg.BrowserHeadless, a.cfg.BrowserWidth, a.cfg.BrowserHeight)
+	b, err := browser.New(a.cfg.BrowserHeadless, a.cfg.BrowserWidth, a.cfg.BrowserHeight, a.cfg.DisableSiteIsolation, a.identity(task))
 	if err != nil {
 		a.failTask(taskID, fmt.Sprintf("failed to start browser: %v", err))
 		return
 	}
 	defer b.Close()
 
+	a.mu.Lock()
+	a.browsers[taskID] = b
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.browsers, taskID)
+		a.mu.Unlock()
+		a.endScreencast(taskID)
+	}()
+
+	if len(task.InputFiles) > 0 {
+		b.SetUploadDir(a.inputDir(taskID))
+	}
+	if err := b.EnableDownloads(a.downloadDir(taskID)); err != nil {
+		log.Printf("[Task %s] Downloads disabled: %v", taskID, err)
+	}
+	b.SetCaptureDir(a.captureDir(taskID))
+	dialogPolicy, _ := browser.ParseDialogPolicy(a.cfg.DialogPolicy)
+	if task.DialogPolicy != "" {
+		dialogPolicy, _ = browser.ParseDialogPolicy(task.DialogPolicy)
+	}
+	b.SetDialogPolicy(dialogPolicy)
+	b.SetSettleOptions(browser.SettleOptions{
+		NetworkIdle: a.cfg.SettleNetworkIdle,
+		DOMQuiet:    a.cfg.SettleDOMQuiet,
+		Max:         a.cfg.SettleMax,
+	})
+	b.SetNetworkCapture(a.networkCapture(task))
+	policies := a.domainPolicies(task)
+	if err := b.SetDomainPolicies(policies...); err != nil {
+		a.failTask(taskID, fmt.Sprintf("failed to apply domain policy: %v", err))
+		return
+	}
+	if err := b.SetResourcePolicy(a.resourcePolicy(task)); err != nil {
+		a.failTask(taskID, fmt.Sprintf("failed to apply resource blocking: %v", err))
+		return
+	}
+	if err := b.SetEmulation(a.emulation(task)); err != nil {
+		a.failTask(taskID, fmt.Sprintf("failed to apply emulation: %v", err))
+		return
+	}
+	a.watchConsole(taskID, b)
+	a.startScreencast(taskID, b)
+
 	ctx := context.Background()
 	llmErrorStreak := 0
+	var lastScreenshot []byte
+	var lastSnapshot *pageSnapshot
+	failures := selectorFailures{counts: make(map[string]int)}
+	summarized := 0       // log entries already folded into the summary
+	screenshotShrink := 0 // kept for the rest of the task once the provider rejects an image
 
 	for i := 0; i < a.cfg.MaxIterations; i++ {
 		log.Printf("[Task %s] Iteration %d/%d", taskID, i+1, a.cfg.MaxIterations)
 
 		// --- OBSERVE ---
-		screenshotBytes, err := b.Screenshot()
+		a.collectDownloads(taskID, b, i)
+		a.collectCaptures(taskID, b, i)
+		a.collectBlocked(taskID, b)
+
+		// Follow popups opened by the last action and tabs that closed themselves
+		tabNote, err := b.FollowTabs()
 		if err != nil {
-			a.failTask(taskID, fmt.Sprintf("screenshot failed at iteration %d: %v", i+1, err))
-			return
+			log.Printf("[Task %s] Tab switch failed at iteration %d: %v", taskID, i+1, err)
+		} else if tabNote != "" {
+			log.Printf("[Task %s] %s", taskID, tabNote)
+			b.WaitSettled()
 		}
-		b64Screenshot := base64.StdEncoding.EncodeToString(screenshotBytes)
 
-		pageURL, _ := b.GetURL()
-		pageTitle, _ := b.GetTitle()
+		// An open dialog pauses the page's script, so the page can't be marked,
+		// captured or read until it is handled; the model sees the last
+		// screenshot and the dialog text instead
+		dialog := b.PendingDialog()
+		pausedByDialog := dialog != nil && lastScreenshot != nil
+
+		var elements []browser.MarkedElement
+		var screenshotBytes []byte
+		var pageURL, pageTitle string
+		if pausedByDialog {
+			screenshotBytes = lastScreenshot
+			pageURL, pageTitle = dialog.URL, activeTabTitle(b)
+		} else {
+			// Number the interactive elements so the overlay is captured in the screenshot
+			elements, err = b.MarkElements()
+			if err != nil {
+				log.Printf("[Task %s] Element marking failed at iteration %d: %v", taskID, i+1, err)
+			}
+			screenshotBytes, err = b.Screenshot()
+			b.ClearMarks()
+			if err != nil {
+				a.failTask(taskID, fmt.Sprintf("screenshot failed at iteration %d: %v", i+1, err))
+				return
+			}
+			lastScreenshot = screenshotBytes
+
+			pageURL, _ = b.GetURL()
+			pageTitle, _ = b.GetTitle()
+		}
+		a.visitPage(taskID, &failures, pageURL)
+		b64Screenshot := base64.StdEncoding.EncodeToString(screenshotBytes)
 
 		// Send screenshot to WebSocket subscribers
 		a.broadcast(taskID, models.WSEvent{
@@ -282,14 +335,73 @@ func (a *Agent) runLoop(taskID string) {
 		a.mu.RLock()
 		history := make([]models.Step, len(task.Steps))
 		copy(history, task.Steps)
-		currentSummary := task.Summary
-		blockedSelectors := task.BlockedSelectors
+		memory := memoryText(task.Summary, task.Log[summarized:])
+		blockedSelectors := selectorNames(task.BlockedSelectors)
+		downloads := artifactNames(task.Artifacts, models.ArtifactDownload)
 		a.mu.RUnlock()
 
-		domContent, _ := b.GetFullDOM()
-		axTree, _ := b.GetAccessibilityTree()
+		var domContent, axTree string
+		var nodes []browser.DOMNode
+		domRead := false
+		if !pausedByDialog {
+			if nodes, err = b.DistillDOM(); err != nil {
+				log.Printf("[Task %s] DOM distillation failed at iteration %d: %v", taskID, i+1, err)
+			} else {
+				domRead = true
+				domContent = browser.RenderDOM(nodes, relevanceQuery(task.Prompt, history), a.cfg.DOMTokenBudget)
+			}
+			axTree, _ = b.GetAccessibilityTree()
+		}
 
-		llmResp, err := a.llmClient.Decide(ctx, SystemPrompt, screenshotBytes, pageURL, pageTitle, task.Prompt, history, domContent, axTree, currentSummary, blockedSelectors)
+		// Compare with the previous observation to show what the last action did
+		handledDialogs := b.HandledDialogs()
+		snapshot := takeSnapshot(pageURL, dialog, nodes, domRead, lastSnapshot)
+		changes := diffSnapshots(lastSnapshot, snapshot, handledDialogs)
+		lastSnapshot = snapshot
+
+		obs := llm.Observation{
+			TaskPrompt:       task.Prompt,
+			ScreenshotDetail: a.cfg.ScreenshotDetail,
+			PageURL:          pageURL,
+			PageTitle:        pageTitle,
+			History:          history,
+			DOM:              domContent,
+			AXTree:           axTree,
+			Elements:         browser.DescribeElements(elements),
+			Tabs:             browser.DescribeTabs(b.Tabs()),
+			TabNote:          tabNote,
+			Dialog:           describeDialogs(dialog, handledDialogs),
+			Changes:          describeChanges(changes),
+			ConsoleErrors:    consoleErrors(history),
+			Policy:           describePolicy(policies, b.BlockedRequests()),
+			Files:            task.InputFiles,
+			Downloads:        downloads,
+			Summary:          memory,
+			BlockedSelectors: blockedSelectors,
+		}
+
+		// The model sees a scaled-down copy; coordinates it returns are mapped
+		// from that size, and a rejected image is retried smaller
+		var shot *modelScreenshot
+		var llmResp *models.LLMResponse
+		for {
+			shot, err = fitScreenshot(screenshotBytes, a.screenshotOptions(screenshotShrink))
+			if err != nil {
+				a.failTask(taskID, fmt.Sprintf("screenshot failed at iteration %d: %v", i+1, err))
+				return
+			}
+			b.SetScreenshotSize(shot.width, shot.height)
+			obs.Screenshot, obs.ScreenshotMIME = shot.data, shot.mimeType
+
+			llmResp, err = a.llmClient.Decide(ctx, systemPrompt(a.cfg.BrowserHeadless), obs)
+			statusCode, rejected := payloadRejected(err)
+			if !rejected || screenshotShrink >= maxScreenshotShrink {
+				break
+			}
+			screenshotShrink++
+			log.Printf("[Task %s] LLM rejected the %dx%d screenshot (%d bytes) with status %d; retrying smaller",
+				taskID, shot.width, shot.height, len(shot.data), statusCode)
+		}
 		if err != nil {
 			llmErrorStreak++
 			log.Printf("[Task %s] LLM error at iteration %d: %v", taskID, i+1, err)
@@ -315,36 +427,35 @@ func (a *Agent) runLoop(taskID string) {
 		}
 		llmErrorStreak = 0
 
-		log.Printf("[Task %s] LLM: thought=%q action=%s selector=%q value=%q done=%v success=%v",
-			taskID, llmResp.Thought, llmResp.Action, llmResp.Selector, llmResp.Value, llmResp.Done, llmResp.Success)
+		log.Printf("[Task %s] LLM: thought=%q action=%s element=%d selector=%q value=%q done=%v success=%v",
+			taskID, llmResp.Thought, llmResp.Action, llmResp.Element, llmResp.Selector, llmResp.Value, llmResp.Done, llmResp.Success)
+
+		cursor := shot.toOriginal(actionCursor(b, llmResp, elements))
 
 		// Check for completion BEFORE executing
 		if llmResp.Done {
 			// Create final step
 			step := models.Step{
-				Iteration:  i + 1,
-				Screenshot: b64Screenshot,
-				URL:        pageURL,
-				Title:      pageTitle,
-				Thought:    llmResp.Thought,
-				Action: models.Action{
-					Type:     models.ActionType(llmResp.Action),
-					Selector: llmResp.Selector,
-					Value:    llmResp.Value,
-					Done:     llmResp.Done,
-					Success:  llmResp.Success,
-				},
+				Iteration:        i + 1,
+				Screenshot:       b64Screenshot,
+				URL:              pageURL,
+				Title:            pageTitle,
+				Thought:          llmResp.Thought,
+				Action:           actionFromResponse(llmResp),
+				Cursor:           cursor,
+				Changes:          changes,
 				ExecutionSuccess: true,
 				ExecutionError:   "",
 				Timestamp:        time.Now(),
+				Console:          collectConsole(b),
+				Network:          collectNetwork(b),
 			}
 
 			a.mu.Lock()
 			task.Steps = append(task.Steps, step)
 			a.mu.Unlock()
 
-			// Update the task summary with final step
-			a.updateSummary(taskID, i+1, step.Action, step.Thought, true, "", pageURL)
+			a.logStep(taskID, step)
 
 			a.broadcast(taskID, models.WSEvent{
 				Type:   models.WSEventStepComplete,
@@ -352,6 +463,9 @@ func (a *Agent) runLoop(taskID string) {
 				Step:   &step,
 			})
 
+			a.collectDownloads(taskID, b, i+1)
+			a.collectCaptures(taskID, b, i+1)
+			a.collectBlocked(taskID, b)
 			if llmResp.Success {
 				a.completeTask(taskID)
 			} else {
@@ -364,36 +478,40 @@ func (a *Agent) runLoop(taskID string) {
 		execSuccess := true
 		execError := ""
 
-		if err := ExecuteAction(b, llmResp); err != nil {
+		// A blocked selector is not tried again; the failed step tells the
+		// model why
+		if llmResp.Element == 0 && slices.Contains(blockedSelectors, llmResp.Selector) {
+			execSuccess = false
+			execError = fmt.Sprintf("selector %q is blocked; find the element another way", llmResp.Selector)
+		} else if err := executeUntilDialog(b, llmResp); err != nil {
 			log.Printf("[Task %s] Action error at iteration %d: %v", taskID, i+1, err)
 			execSuccess = false
 			execError = err.Error()
+			if llmResp.Element == 0 {
+				a.recordSelectorFailure(taskID, &failures, llmResp.Selector, execError)
+			}
 		}
 
-		// Wait for page to settle (longer for clicks/navigates)
-		if llmResp.Action == "click" || llmResp.Action == "navigate" {
-			time.Sleep(3 * time.Second)
-		} else {
-			time.Sleep(1 * time.Second)
+		// Wait for loads, requests and DOM updates the action triggered
+		if !b.WaitSettled() {
+			log.Printf("[Task %s] Page still busy after %s at iteration %d", taskID, a.cfg.SettleMax, i+1)
 		}
 
 		// NOW create the step with execution results
 		step := models.Step{
-			Iteration:  i + 1,
-			Screenshot: b64Screenshot,
-			URL:        pageURL,
-			Title:      pageTitle,
-			Thought:    llmResp.Thought,
-			Action: models.Action{
-				Type:     models.ActionType(llmResp.Action),
-				Selector: llmResp.Selector,
-				Value:    llmResp.Value,
-				Done:     llmResp.Done,
-				Success:  llmResp.Success,
-			},
+			Iteration:        i + 1,
+			Screenshot:       b64Screenshot,
+			URL:              pageURL,
+			Title:            pageTitle,
+			Thought:          llmResp.Thought,
+			Action:           actionFromResponse(llmResp),
+			Cursor:           cursor,
+			Changes:          changes,
 			ExecutionSuccess: execSuccess,
 			ExecutionError:   execError,
 			Timestamp:        time.Now(),
+			Console:          collectConsole(b),
+			Network:          collectNetwork(b),
 		}
 
 		// Store the step with execution results
@@ -401,8 +519,9 @@ func (a *Agent) runLoop(taskID string) {
 		task.Steps = append(task.Steps, step)
 		a.mu.Unlock()
 
-		// Update the task summary with this step's information
-		a.updateSummary(taskID, i+1, step.Action, step.Thought, execSuccess, execError, pageURL)
+		// Record the step and periodically fold the log into the summary
+		a.logStep(taskID, step)
+		summarized = a.compactMemory(ctx, taskID, summarized)
 
 		// Broadcast step with execution results
 		a.broadcast(taskID, models.WSEvent{
@@ -412,9 +531,28 @@ func (a *Agent) runLoop(taskID string) {
 		})
 	}
 
+	a.collectDownloads(taskID, b, a.cfg.MaxIterations)
+	a.collectCaptures(taskID, b, a.cfg.MaxIterations)
+	a.collectBlocked(taskID, b)
 	a.failTask(taskID, fmt.Sprintf("max iterations (%d) reached without completing task", a.cfg.MaxIterations))
 }
 
+// actionFromResponse converts the model's decision into the action recorded on a step.
+func actionFromResponse(resp *models.LLMResponse) models.Action {
+	return models.Action{
+		Type:     models.ActionType(resp.Action),
+		Element:  resp.Element,
+		Selector: resp.Selector,
+		Value:    resp.Value,
+		X:        resp.X,
+		Y:        resp.Y,
+		ToX:      resp.ToX,
+		ToY:      resp.ToY,
+		Done:     resp.Done,
+		Success:  resp.Success,
+	}
+}
+
 func nonRetryableLLMStatus(err error) (int, bool) {
 	var apiErr *llm.APIError
 	if !errors.As(err, &apiErr) {
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// collectDownloads records downloads the browser finished since the last call
+// as task artifacts.
+func (a *Agent) collectDownloads(taskID string, b *browser.Browser, iteration int) {
+	downloads := b.CompletedDownloads()
+	if len(downloads) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	for _, d := range downloads {
+		task.Artifacts = append(task.Artifacts, models.Artifact{
+			Name:      artifactName(task.Artifacts, d.Name),
+			Kind:      models.ArtifactDownload,
+			Size:      d.Size,
+			MIMEType:  d.MIMEType,
+			SourceURL: d.URL,
+			Iteration: iteration,
+			CreatedAt: time.Now(),
+			Path:      d.Path,
+		})
+		log.Printf("[Task %s] Downloaded %s (%d bytes)", taskID, d.Name, d.Size)
+	}
+}
+
+// collectCaptures records screenshots and PDFs saved since the last call as
+// task artifacts.
+func (a *Agent) collectCaptures(taskID string, b *browser.Browser, iteration int) {
+	captures := b.Captures()
+	if len(captures) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	for _, c := range captures {
+		art := captureArtifact(c, iteration)
+		art.Name = artifactName(task.Artifacts, art.Name)
+		task.Artifacts = append(task.Artifacts, art)
+		log.Printf("[Task %s] Saved %s %s (%d bytes)", taskID, c.Kind, c.Name, c.Size)
+	}
+}
+
+// captureArtifact describes a saved capture as an artifact.
+func captureArtifact(c browser.Capture, iteration int) models.Artifact {
+	kind := models.ArtifactScreenshot
+	if c.Kind == browser.CapturePDF {
+		kind = models.ArtifactPDF
+	}
+	return models.Artifact{
+		Name:      c.Name,
+		Kind:      kind,
+		Size:      c.Size,
+		MIMEType:  c.MIMEType,
+		SourceURL: c.URL,
+		Iteration: iteration,
+		CreatedAt: time.Now(),
+		Path:      c.Path,
+	}
+}
+
+// runningBrowser returns the browser of a running task.
+func (a *Agent) runningBrowser(taskID string) (*browser.Browser, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if _, ok := a.tasks[taskID]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	b, ok := a.browsers[taskID]
+	if !ok {
+		return nil, ErrTaskNotRunning
+	}
+	return b, nil
+}
+
+// Capture takes a screenshot or PDF of a running task's active tab without
+// keeping it. Elements are selected by CSS selector.
+func (a *Agent) Capture(taskID string, kind browser.CaptureKind, selector string) ([]byte, string, error) {
+	b, err := a.runningBrowser(taskID)
+	if err != nil {
+		return nil, "", err
+	}
+	if kind == browser.CaptureElement && selector == "" {
+		return nil, "", fmt.Errorf("element captures require a selector")
+	}
+	return b.CaptureBytes(kind, browser.Target{Selector: selector})
+}
+
+// SaveCapture takes a capture of a running task's active tab and stores it
+// as a task artifact.
+func (a *Agent) SaveCapture(taskID string, kind browser.CaptureKind, selector string) (*models.Artifact, error) {
+	b, err := a.runningBrowser(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if kind == browser.CaptureElement && selector == "" {
+		return nil, fmt.Errorf("element captures require a selector")
+	}
+	name := "screenshot.png"
+	if kind == browser.CapturePDF {
+		name = "page.pdf"
+	}
+	c, err := b.WriteCapture(kind, browser.Target{Selector: selector}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	art := captureArtifact(c, len(task.Steps))
+	art.Name = artifactName(task.Artifacts, art.Name)
+	task.Artifacts = append(task.Artifacts, art)
+	return &art, nil
+}
+
+// artifactName numbers name like a duplicate file name when the task already
+// has an artifact of that name, so names identify artifacts of every kind.
+func artifactName(artifacts []models.Artifact, name string) string {
+	taken := make(map[string]bool, len(artifacts))
+	for _, art := range artifacts {
+		taken[art.Name] = true
+	}
+	return browser.FreeName(name, func(n string) bool { return taken[n] })
+}
+
+// artifactNames lists the names of a task's artifacts of the given kind.
+func artifactNames(artifacts []models.Artifact, kind models.ArtifactKind) []string {
+	var names []string
+	for _, art := range artifacts {
+		if art.Kind == kind {
+			names = append(names, art.Name)
+		}
+	}
+	return names
+}
+
+// GetArtifacts returns a copy of the artifacts a task produced.
+func (a *Agent) GetArtifacts(taskID string) ([]models.Artifact, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	task, ok := a.tasks[taskID]
+	if !ok {
+		return nil, false
+	}
+	artifacts := make([]models.Artifact, len(task.Artifacts))
+	copy(artifacts, task.Artifacts)
+	return artifacts, true
+}
+
+// GetArtifact looks up one artifact of a task by its name, which is unique
+// within the task.
+func (a *Agent) GetArtifact(taskID, name string) (*models.Artifact, bool) {
+	artifacts, ok := a.GetArtifacts(taskID)
+	if !ok {
+		return nil, false
+	}
+	for i := range artifacts {
+		if artifacts[i].Name == name {
+			return &artifacts[i], true
+		}
+	}
+	return nil, false
+}
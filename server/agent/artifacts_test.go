@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/anamika/zenact-web/server/models"
+)
+
+func TestArtifactName(t *testing.T) {
+	artifacts := []models.Artifact{
+		{Name: "page.pdf", Kind: models.ArtifactPDF},
+		{Name: "report.pdf", Kind: models.ArtifactDownload},
+		{Name: "report (1).pdf", Kind: models.ArtifactPDF},
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"screenshot.png", "screenshot.png"},
+		{"page.pdf", "page (1).pdf"},
+		{"report.pdf", "report (2).pdf"},
+	}
+	for _, tt := range tests {
+		if got := artifactName(artifacts, tt.name); got != tt.want {
+			t.Errorf("artifactName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// maxConsoleErrors is how many of the last step's page errors the model sees.
+const maxConsoleErrors = 10
+
+func consoleMessage(m browser.ConsoleMessage) models.ConsoleMessage {
+	return models.ConsoleMessage{
+		Level:     m.Level,
+		Text:      m.Text,
+		URL:       m.URL,
+		Line:      m.Line,
+		Exception: m.Exception,
+		Timestamp: m.Time,
+	}
+}
+
+// watchConsole streams the page's console output to the task's subscribers.
+func (a *Agent) watchConsole(taskID string, b *browser.Browser) {
+	b.OnConsole(func(m browser.ConsoleMessage) {
+		msg := consoleMessage(m)
+		a.broadcast(taskID, models.WSEvent{
+			Type:    models.WSEventConsole,
+			TaskID:  taskID,
+			Console: &msg,
+		})
+	})
+}
+
+// collectConsole returns the console output since the last call.
+func collectConsole(b *browser.Browser) []models.ConsoleMessage {
+	msgs := b.ConsoleMessages()
+	if len(msgs) == 0 {
+		return nil
+	}
+	out := make([]models.ConsoleMessage, len(msgs))
+	for i, m := range msgs {
+		out[i] = consoleMessage(m)
+	}
+	return out
+}
+
+// consoleErrors lists the errors and uncaught exceptions of the most recent
+// step, newest last.
+func consoleErrors(history []models.Step) []string {
+	if len(history) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, m := range history[len(history)-1].Console {
+		if !m.Exception && m.Level != "error" && m.Level != "assert" {
+			continue
+		}
+		// Exceptions carry their stack after the first line
+		text, _, _ := strings.Cut(m.Text, "\n")
+		line := fmt.Sprintf("[%s] %s", m.Level, text)
+		if m.Exception {
+			line = "[uncaught] " + text
+		}
+		if m.URL != "" {
+			line += fmt.Sprintf(" (%s:%d)", m.URL, m.Line)
+		}
+		errs = append(errs, line)
+	}
+	if len(errs) > maxConsoleErrors {
+		errs = errs[len(errs)-maxConsoleErrors:]
+	}
+	return errs
+}
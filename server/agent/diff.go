@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// maxChangeLines caps each list of page changes.
+const maxChangeLines = 12
+
+// pageSnapshot is what one observation saw, kept to tell the model what its
+// next action changed. Node descriptions include values and checked and
+// disabled states, so state changes show up as changed nodes.
+type pageSnapshot struct {
+	url    string
+	dialog string            // the open dialog, if any
+	nodes  map[string]string // distilled node description by kind and selector
+	order  []string          // node keys in document order
+
+	unreadable bool // reading the page failed; nodes are the previous ones
+}
+
+// takeSnapshot records an observation. When the page was not read, as while
+// a dialog blocks it or when distilling it failed, the previous snapshot's
+// elements carry over so the next readable page is compared with them.
+func takeSnapshot(url string, dialog *browser.Dialog, nodes []browser.DOMNode, read bool, prev *pageSnapshot) *pageSnapshot {
+	s := &pageSnapshot{url: url}
+	if dialog != nil {
+		s.dialog = fmt.Sprintf("%s %q", dialog.Type, dialog.Message)
+	}
+	if !read {
+		s.unreadable = dialog == nil
+		if prev != nil {
+			s.nodes, s.order = prev.nodes, prev.order
+		}
+		return s
+	}
+
+	s.nodes = make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		key := n.Kind + "|" + n.Selector
+		if _, dup := s.nodes[key]; dup {
+			continue
+		}
+		// Scrolling and re-marking are not changes to the page
+		n.Distance, n.Mark = 0, 0
+		s.nodes[key] = browser.DescribeNode(n)
+		s.order = append(s.order, key)
+	}
+	return s
+}
+
+// diffSnapshots compares two observations. handled are dialogs the policy
+// closed in between. It returns nil when there is no previous observation.
+func diffSnapshots(prev, cur *pageSnapshot, handled []browser.Dialog) *models.PageChanges {
+	if prev == nil {
+		return nil
+	}
+	c := &models.PageChanges{}
+	if prev.url != cur.url {
+		c.URLFrom, c.URLTo = prev.url, cur.url
+	}
+	for _, d := range handled {
+		c.Dialogs = append(c.Dialogs, fmt.Sprintf("%s %q (%s automatically)", d.Type, d.Message, d.Result))
+	}
+	if cur.dialog != "" && cur.dialog != prev.dialog {
+		c.Dialogs = append(c.Dialogs, cur.dialog+" (open)")
+	}
+
+	if cur.unreadable {
+		c.Unreadable = true
+		return c
+	}
+
+	add := func(list *[]string, line string) {
+		if len(*list) >= maxChangeLines {
+			c.Omitted++
+			return
+		}
+		*list = append(*list, line)
+	}
+	for _, key := range cur.order {
+		before, ok := prev.nodes[key]
+		switch {
+		case !ok:
+			add(&c.Added, cur.nodes[key])
+		case before != cur.nodes[key]:
+			add(&c.Changed, before+" -> "+cur.nodes[key])
+		}
+	}
+	for _, key := range prev.order {
+		if _, ok := cur.nodes[key]; !ok {
+			add(&c.Removed, prev.nodes[key])
+		}
+	}
+	return c
+}
+
+// describeChanges renders page changes for the model.
+func describeChanges(c *models.PageChanges) string {
+	if c == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if c.URLTo != "" {
+		fmt.Fprintf(&sb, "URL: %s -> %s\n", c.URLFrom, c.URLTo)
+	}
+	for _, d := range c.Dialogs {
+		fmt.Fprintf(&sb, "Dialog: %s\n", d)
+	}
+	for _, list := range []struct {
+		mark  string
+		lines []string
+	}{{"+", c.Added}, {"-", c.Removed}, {"~", c.Changed}} {
+		for _, line := range list.lines {
+			fmt.Fprintf(&sb, "%s %s\n", list.mark, line)
+		}
+	}
+	if c.Omitted > 0 {
+		fmt.Fprintf(&sb, "(%d more changes not listed)\n", c.Omitted)
+	}
+	if c.Unreadable {
+		sb.WriteString("The page could not be read this time, so changes to its elements are unknown; judge the effect of your last action from the screenshot.\n")
+	}
+	if sb.Len() == 0 {
+		return "Nothing changed on the page. If your last action should have had a visible effect, it probably missed; try a different element or approach.\n"
+	}
+	return sb.String()
+}
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+func button(selector, text string) browser.DOMNode {
+	return browser.DOMNode{Kind: browser.NodeInteractive, Tag: "button", Selector: selector, Text: text}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	ok := button("#ok", "OK")
+	cancel := button("#cancel", "Cancel")
+	marked := ok
+	marked.Mark, marked.Distance = 3, 200
+	field := browser.DOMNode{Kind: browser.NodeInteractive, Tag: "input", Selector: "#q", Attrs: map[string]string{"value": ""}}
+	filled := field
+	filled.Attrs = map[string]string{"value": "shoes"}
+	alert := &browser.Dialog{Type: "alert", Message: "Saved"}
+
+	page := func(url string, nodes ...browser.DOMNode) *pageSnapshot {
+		return takeSnapshot(url, nil, nodes, true, nil)
+	}
+
+	tests := []struct {
+		name    string
+		prev    *pageSnapshot
+		cur     func(prev *pageSnapshot) *pageSnapshot
+		handled []browser.Dialog
+		want    *models.PageChanges
+	}{
+		{
+			name: "first observation",
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://a.test", ok) },
+		},
+		{
+			name: "nothing changed",
+			prev: page("https://a.test", ok, cancel),
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://a.test", ok, cancel) },
+			want: &models.PageChanges{},
+		},
+		{
+			name: "marks and scrolling are not changes",
+			prev: page("https://a.test", ok),
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://a.test", marked) },
+			want: &models.PageChanges{},
+		},
+		{
+			name: "added, removed and changed",
+			prev: page("https://a.test", ok, field),
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://a.test", cancel, filled) },
+			want: &models.PageChanges{
+				Added:   []string{`button #cancel "Cancel"`},
+				Removed: []string{`button #ok "OK"`},
+				Changed: []string{`input #q value="" -> input #q value="shoes"`},
+			},
+		},
+		{
+			name: "navigation",
+			prev: page("https://a.test", ok),
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://b.test", ok) },
+			want: &models.PageChanges{URLFrom: "https://a.test", URLTo: "https://b.test"},
+		},
+		{
+			name: "dialog opened",
+			prev: page("https://a.test", ok),
+			cur: func(prev *pageSnapshot) *pageSnapshot {
+				return takeSnapshot("https://a.test", alert, nil, false, prev)
+			},
+			want: &models.PageChanges{Dialogs: []string{`alert "Saved" (open)`}},
+		},
+		{
+			name:    "dialog handled by policy",
+			prev:    page("https://a.test", ok),
+			cur:     func(*pageSnapshot) *pageSnapshot { return page("https://a.test", ok) },
+			handled: []browser.Dialog{{Type: "confirm", Message: "Leave?", Result: "accepted"}},
+			want:    &models.PageChanges{Dialogs: []string{`confirm "Leave?" (accepted automatically)`}},
+		},
+		{
+			name: "unreadable page",
+			prev: page("https://a.test", ok),
+			cur: func(prev *pageSnapshot) *pageSnapshot {
+				return takeSnapshot("https://a.test", nil, nil, false, prev)
+			},
+			want: &models.PageChanges{Unreadable: true},
+		},
+		{
+			name: "compared with the last readable page",
+			prev: takeSnapshot("https://a.test", alert, nil, false, page("https://a.test", ok)),
+			cur:  func(*pageSnapshot) *pageSnapshot { return page("https://a.test", ok, cancel) },
+			want: &models.PageChanges{Added: []string{`button #cancel "Cancel"`}},
+		},
+	}
+	for _, tt := range tests {
+		got := diffSnapshots(tt.prev, tt.cur(tt.prev), tt.handled)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diffSnapshots = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffSnapshotsOmitsOverflow(t *testing.T) {
+	var nodes []browser.DOMNode
+	for i := 0; i < maxChangeLines+3; i++ {
+		nodes = append(nodes, button(fmt.Sprintf("#b%d", i), "B"))
+	}
+	got := diffSnapshots(takeSnapshot("u", nil, nil, true, nil), takeSnapshot("u", nil, nodes, true, nil), nil)
+	if len(got.Added) != maxChangeLines || got.Omitted != 3 {
+		t.Errorf("diffSnapshots listed %d and omitted %d, want %d and 3", len(got.Added), got.Omitted, maxChangeLines)
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes *models.PageChanges
+		want    []string
+	}{
+		{name: "no previous observation", changes: nil, want: nil},
+		{name: "nothing changed", changes: &models.PageChanges{}, want: []string{"Nothing changed on the page."}},
+		{
+			name: "changes",
+			changes: &models.PageChanges{
+				URLFrom: "https://a.test", URLTo: "https://b.test",
+				Dialogs: []string{`alert "Hi" (open)`},
+				Added:   []string{"button #a"},
+				Removed: []string{"button #b"},
+				Changed: []string{"input #c -> input #c value=\"x\""},
+				Omitted: 2,
+			},
+			want: []string{
+				"URL: https://a.test -> https://b.test\n",
+				"Dialog: alert \"Hi\" (open)\n",
+				"+ button #a\n",
+				"- button #b\n",
+				"~ input #c -> input #c value=\"x\"\n",
+				"(2 more changes not listed)\n",
+			},
+		},
+		{name: "unreadable", changes: &models.PageChanges{Unreadable: true}, want: []string{"could not be read"}},
+	}
+	for _, tt := range tests {
+		got := describeChanges(tt.changes)
+		if tt.want == nil && got != "" {
+			t.Errorf("%s: describeChanges = %q, want empty", tt.name, got)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(got, s) {
+				t.Errorf("%s: describeChanges = %q, want it to contain %q", tt.name, got, s)
+			}
+		}
+		if tt.changes != nil && tt.changes.Unreadable && strings.Contains(got, "Nothing changed") {
+			t.Errorf("%s: describeChanges claims nothing changed on an unreadable page", tt.name)
+		}
+	}
+}
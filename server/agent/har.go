@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+	"github.com/chromedp/cdproto/har"
+)
+
+// networkCapture builds the browser's capture options for a task.
+func (a *Agent) networkCapture(task *models.Task) browser.NetworkCaptureOptions {
+	bodies := a.cfg.NetworkCaptureBodies
+	if task.CaptureBodies != nil {
+		bodies = *task.CaptureBodies
+	}
+	// Extra headers are hidden from the task JSON and often carry tokens
+	var redact []string
+	for name := range a.identity(task).Headers {
+		redact = append(redact, name)
+	}
+	return browser.NetworkCaptureOptions{
+		Enabled:       a.cfg.NetworkCapture,
+		Bodies:        bodies,
+		MaxBodyBytes:  int64(a.cfg.NetworkBodyMaxKB) * 1024,
+		MIMETypes:     a.cfg.NetworkBodyMIMETypes,
+		RedactHeaders: redact,
+	}
+}
+
+// collectNetwork returns the exchanges the browser completed since the last call.
+func collectNetwork(b *browser.Browser) []models.NetworkEntry {
+	entries := b.NetworkEntries()
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make([]models.NetworkEntry, len(entries))
+	for i, e := range entries {
+		out[i] = models.NetworkEntry{
+			ResourceType:    e.ResourceType,
+			Method:          e.Method,
+			URL:             e.URL,
+			RequestHeaders:  e.RequestHeaders,
+			PostData:        e.PostData,
+			StartedAt:       e.StartedAt,
+			Status:          e.Status,
+			StatusText:      e.StatusText,
+			Protocol:        e.Protocol,
+			ResponseHeaders: e.ResponseHeaders,
+			MIMEType:        e.MIMEType,
+			RemoteIP:        e.RemoteIP,
+			RedirectURL:     e.RedirectURL,
+			FromCache:       e.FromCache,
+			TransferSize:    e.TransferSize,
+			Body:            e.Body,
+			BodyEncoding:    e.BodyEncoding,
+			BodySize:        e.BodySize,
+			Timings:         models.NetworkTimings(e.Timings),
+			Error:           e.Error,
+		}
+	}
+	return out
+}
+
+// GetHAR exports the traffic of a task as a HAR log with one page per step.
+func (a *Agent) GetHAR(taskID string) (*har.HAR, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	task, ok := a.tasks[taskID]
+	if !ok {
+		return nil, false
+	}
+
+	log := &har.Log{
+		Version: "1.2",
+		Creator: &har.Creator{Name: "zenact-web", Version: "1.0"},
+		Entries: []*har.Entry{},
+	}
+	for _, step := range task.Steps {
+		pageID := fmt.Sprintf("step_%d", step.Iteration)
+		title := fmt.Sprintf("Step %d: %s", step.Iteration, step.Action.Type)
+		if step.Title != "" {
+			title += " on " + step.Title
+		}
+		log.Pages = append(log.Pages, &har.Page{
+			StartedDateTime: harTime(step.Timestamp),
+			ID:              pageID,
+			Title:           title,
+			PageTimings:     &har.PageTimings{},
+		})
+		for _, e := range step.Network {
+			log.Entries = append(log.Entries, harEntry(pageID, e))
+		}
+	}
+	sort.SliceStable(log.Entries, func(i, j int) bool {
+		return log.Entries[i].StartedDateTime < log.Entries[j].StartedDateTime
+	})
+	return &har.HAR{Log: log}, true
+}
+
+func harEntry(pageID string, e models.NetworkEntry) *har.Entry {
+	httpVersion := strings.ToUpper(e.Protocol)
+	if httpVersion == "" {
+		httpVersion = "HTTP/1.1"
+	}
+
+	req := &har.Request{
+		Method:      e.Method,
+		URL:         e.URL,
+		HTTPVersion: httpVersion,
+		Cookies:     []*har.Cookie{},
+		Headers:     harHeaders(e.RequestHeaders),
+		QueryString: harQuery(e.URL),
+		HeadersSize: -1,
+		BodySize:    int64(len(e.PostData)),
+	}
+	if e.PostData != "" {
+		req.PostData = &har.PostData{
+			MimeType: e.RequestHeaders["Content-Type"],
+			Params:   []*har.Param{},
+			Text:     e.PostData,
+		}
+	}
+
+	resp := &har.Response{
+		Status:      e.Status,
+		StatusText:  e.StatusText,
+		HTTPVersion: httpVersion,
+		Cookies:     []*har.Cookie{},
+		Headers:     harHeaders(e.ResponseHeaders),
+		Content: &har.Content{
+			Size:     e.BodySize,
+			MimeType: e.MIMEType,
+			Text:     e.Body,
+			Encoding: e.BodyEncoding,
+		},
+		RedirectURL: e.RedirectURL,
+		HeadersSize: -1,
+		BodySize:    e.TransferSize,
+	}
+	if e.FromCache {
+		resp.BodySize = 0
+	}
+
+	return &har.Entry{
+		Pageref:         pageID,
+		StartedDateTime: harTime(e.StartedAt),
+		Time:            e.Timings.Total,
+		Request:         req,
+		Response:        resp,
+		Cache:           &har.Cache{},
+		Timings: &har.Timings{
+			Blocked: e.Timings.Blocked,
+			DNS:     e.Timings.DNS,
+			Connect: e.Timings.Connect,
+			Ssl:     e.Timings.SSL,
+			Send:    e.Timings.Send,
+			Wait:    e.Timings.Wait,
+			Receive: e.Timings.Receive,
+		},
+		ServerIPAddress: strings.Trim(e.RemoteIP, "[]"),
+		Comment:         e.Error,
+	}
+}
+
+func harHeaders(h map[string]string) []*har.NameValuePair {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []*har.NameValuePair{}
+	for _, name := range names {
+		// Chrome joins repeated headers with newlines
+		for _, v := range strings.Split(h[name], "\n") {
+			pairs = append(pairs, &har.NameValuePair{Name: name, Value: v})
+		}
+	}
+	return pairs
+}
+
+func harQuery(rawURL string) []*har.NameValuePair {
+	pairs := []*har.NameValuePair{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			pairs = append(pairs, &har.NameValuePair{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func harTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
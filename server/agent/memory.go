@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// maxPendingEntries bounds the log entries shown to the model next to the
+// summary before they are folded into it.
+const maxPendingEntries = 15
+
+// logStep appends a step to the task's raw log.
+func (a *Agent) logStep(taskID string, step models.Step) {
+	entry := fmt.Sprintf("Step %d on %s: %s", step.Iteration, step.URL, describeAction(step.Action))
+	if step.ExecutionSuccess {
+		entry += " -> ok"
+	} else {
+		entry += " -> FAILED: " + truncate(step.ExecutionError, 200)
+	}
+	if step.Thought != "" {
+		entry += " | " + truncate(step.Thought, 200)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	task.Log = append(task.Log, entry)
+}
+
+// compactMemory has the model fold the log entries from index from onwards
+// into the task summary once enough have accumulated. It returns the index of
+// the first entry still to be folded; on failure the entries stay pending.
+func (a *Agent) compactMemory(ctx context.Context, taskID string, from int) int {
+	a.mu.RLock()
+	task := a.tasks[taskID]
+	prompt, summary := task.Prompt, task.Summary
+	entries := append([]string(nil), task.Log[from:]...)
+	a.mu.RUnlock()
+
+	if a.cfg.SummaryInterval <= 0 || len(entries) < a.cfg.SummaryInterval {
+		return from
+	}
+	updated, err := a.llmClient.Summarize(ctx, prompt, summary, entries, a.cfg.SummaryTokenBudget)
+	if err != nil {
+		log.Printf("[Task %s] Summarizing %d log entries failed: %v", taskID, len(entries), err)
+		return from
+	}
+
+	a.mu.Lock()
+	task.Summary = clipToTokens(updated, a.cfg.SummaryTokenBudget)
+	a.mu.Unlock()
+	return from + len(entries)
+}
+
+// memoryText is what the model is shown as its memory: the summary followed
+// by the most recent log entries not yet folded into it.
+func memoryText(summary string, pending []string) string {
+	var sb strings.Builder
+	sb.WriteString(summary)
+	if len(pending) == 0 {
+		return sb.String()
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Steps since this summary:\n")
+	if skipped := len(pending) - maxPendingEntries; skipped > 0 {
+		fmt.Fprintf(&sb, "(%d earlier steps not shown)\n", skipped)
+		pending = pending[skipped:]
+	}
+	for _, entry := range pending {
+		sb.WriteString(entry)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// clipToTokens cuts text at a line boundary to fit the token budget, for
+// models that overrun the length they were asked for.
+func clipToTokens(text string, budget int) string {
+	if browser.EstimateTokens(text) <= budget {
+		return text
+	}
+	n := budget * 4
+	// Never split a multi-byte character
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	cut := text[:n]
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "\n(memory trimmed)"
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClipToTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		budget int
+		want   string
+	}{
+		{name: "fits", text: "short", budget: 10, want: "short"},
+		{name: "exactly fits", text: strings.Repeat("a", 40), budget: 10, want: strings.Repeat("a", 40)},
+		{name: "empty", text: "", budget: 10, want: ""},
+		{
+			name:   "cut at line boundary",
+			text:   "first line\nsecond line\nthird line that is too long",
+			budget: 6,
+			want:   "first line\nsecond line\n(memory trimmed)",
+		},
+		{
+			name:   "single long line",
+			text:   strings.Repeat("x", 50),
+			budget: 5,
+			want:   strings.Repeat("x", 20) + "\n(memory trimmed)",
+		},
+		{
+			name:   "multi-byte character at the cut",
+			text:   strings.Repeat("a", 7) + "é" + strings.Repeat("b", 20),
+			budget: 2,
+			want:   strings.Repeat("a", 7) + "\n(memory trimmed)",
+		},
+	}
+	for _, tt := range tests {
+		got := clipToTokens(tt.text, tt.budget)
+		if got != tt.want {
+			t.Errorf("%s: clipToTokens = %q, want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: clipToTokens returned invalid UTF-8 %q", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// identity merges the configured network identity with the task's overrides.
+func (a *Agent) identity(task *models.Task) browser.Identity {
+	id := browser.Identity{
+		ProxyURL:    a.cfg.ProxyURL,
+		ProxyBypass: a.cfg.ProxyBypass,
+		Headers:     make(map[string]string),
+	}
+	if task.Proxy != "" {
+		id.ProxyURL = task.Proxy
+	}
+	for k, v := range a.cfg.ExtraHTTPHeaders {
+		id.Headers[k] = v
+	}
+	for k, v := range task.ExtraHeaders {
+		id.Headers[k] = v
+	}
+	if a.cfg.BasicAuthUser != "" {
+		id.BasicAuth = &browser.Credentials{
+			Username: a.cfg.BasicAuthUser,
+			Password: a.cfg.BasicAuthPassword,
+			Origin:   a.cfg.BasicAuthOrigin,
+		}
+	}
+	if c := task.BasicAuth; c != nil {
+		id.BasicAuth = &browser.Credentials{Username: c.Username, Password: c.Password, Origin: c.Origin}
+	}
+	return id
+}
+
+// emulation merges the configured emulation defaults with the task's overrides.
+func (a *Agent) emulation(task *models.Task) browser.Emulation {
+	e := browser.Emulation{
+		Device:   a.cfg.Device,
+		Locale:   a.cfg.Locale,
+		Timezone: a.cfg.Timezone,
+	}
+	if g := a.cfg.Geolocation; len(g) >= 2 {
+		e.Geolocation = &browser.Geolocation{Latitude: g[0], Longitude: g[1]}
+		if len(g) == 3 {
+			e.Geolocation.Accuracy = g[2]
+		}
+	}
+	if task.Device != "" {
+		e.Device = task.Device
+	}
+	if task.Locale != "" {
+		e.Locale = task.Locale
+	}
+	if task.Timezone != "" {
+		e.Timezone = task.Timezone
+	}
+	if g := task.Geolocation; g != nil {
+		e.Geolocation = &browser.Geolocation{Latitude: g.Latitude, Longitude: g.Longitude, Accuracy: g.Accuracy}
+	}
+	return e
+}
+
+// domainPolicies returns the server-wide and the task's domain restrictions.
+func (a *Agent) domainPolicies(task *models.Task) []browser.DomainPolicy {
+	return []browser.DomainPolicy{
+		{Allow: a.cfg.AllowedDomains, Deny: a.cfg.DeniedDomains},
+		{Allow: task.AllowedDomains, Deny: task.DeniedDomains},
+	}
+}
+
+// describePolicy tells the model which domains it may visit and what the
+// browser blocked since the last step.
+func describePolicy(policies []browser.DomainPolicy, blocked []browser.BlockedRequest) string {
+	var sb strings.Builder
+	for _, p := range policies {
+		if len(p.Allow) > 0 {
+			fmt.Fprintf(&sb, "Only these domains may be visited: %s\n", strings.Join(p.Allow, ", "))
+		}
+		if len(p.Deny) > 0 {
+			fmt.Fprintf(&sb, "These domains are blocked: %s\n", strings.Join(p.Deny, ", "))
+		}
+	}
+	sb.WriteString(browser.DescribeBlocked(blocked))
+	return sb.String()
+}
+
+// resourcePolicy returns what the task's browser drops: the task's resource
+// types or the configured ones, and the blocklist unless the task opts out.
+func (a *Agent) resourcePolicy(task *models.Task) browser.ResourcePolicy {
+	p := browser.ResourcePolicy{Types: a.cfg.BlockResourceTypes}
+	if task.BlockResources != nil {
+		p.Types = task.BlockResources
+	}
+	if task.UseBlocklist == nil || *task.UseBlocklist {
+		p.Hosts = a.hostBlocklist()
+	}
+	return p
+}
+
+// hostBlocklist loads BLOCKLIST_FILE on first use.
+func (a *Agent) hostBlocklist() *browser.HostList {
+	a.blocklistOnce.Do(func() {
+		if a.cfg.BlocklistFile == "" {
+			return
+		}
+		list, err := browser.LoadHostList(a.cfg.BlocklistFile)
+		if err != nil {
+			log.Printf("WARNING: host blocklist disabled: %v", err)
+			return
+		}
+		log.Printf("Loaded %d blocked hosts from %s", list.Len(), a.cfg.BlocklistFile)
+		a.blocklist = list
+	})
+	return a.blocklist
+}
+
+// collectBlocked adds the requests the browser dropped since the last call to
+// the task's counts.
+func (a *Agent) collectBlocked(taskID string, b *browser.Browser) {
+	counts := b.BlockedCounts()
+	if len(counts) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	if task.BlockedRequests == nil {
+		task.BlockedRequests = make(map[string]int)
+	}
+	for key, n := range counts {
+		task.BlockedRequests[key] += n
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemPrompt(t *testing.T) {
+	tests := []struct {
+		headless bool
+		want     []string
+		wantNot  []string
+	}{
+		{
+			headless: true,
+			want:     []string{"|save_screenshot|save_pdf|scroll|", "- **save_pdf**:"},
+		},
+		{
+			headless: false,
+			want:     []string{"|save_screenshot|scroll|", "- **save_screenshot**:"},
+			wantNot:  []string{"save_pdf"},
+		},
+	}
+	for _, tt := range tests {
+		prompt := systemPrompt(tt.headless)
+		for _, s := range tt.want {
+			if !strings.Contains(prompt, s) {
+				t.Errorf("systemPrompt(%v) does not contain %q", tt.headless, s)
+			}
+		}
+		for _, s := range tt.wantNot {
+			if strings.Contains(prompt, s) {
+				t.Errorf("systemPrompt(%v) contains %q", tt.headless, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"math"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+	"github.com/anamika/zenact-web/server/replay"
+)
+
+// actionCursor returns where on the screenshot an action pointed: its
+// coordinates, or the centre of its numbered element.
+func actionCursor(b *browser.Browser, resp *models.LLMResponse, elements []browser.MarkedElement) *models.Point {
+	if isCoordinateAction(models.ActionType(resp.Action)) {
+		return &models.Point{X: resp.X, Y: resp.Y}
+	}
+	if resp.Element <= 0 {
+		return nil
+	}
+	for _, el := range elements {
+		if el.Index != resp.Element || len(el.Bounds) != 4 {
+			continue
+		}
+		x, y := b.ScreenshotPoint(float64(el.Bounds[0])+float64(el.Bounds[2])/2, float64(el.Bounds[1])+float64(el.Bounds[3])/2)
+		return &models.Point{X: x, Y: y}
+	}
+	return nil
+}
+
+// WriteReplay renders the task's step screenshots as a GIF, or as MJPEG when
+// mjpeg is set. It reports false when the task does not exist.
+func (a *Agent) WriteReplay(w io.Writer, taskID string, mjpeg bool, opts replay.Options) (bool, error) {
+	task, ok := a.GetTask(taskID)
+	if !ok {
+		return false, nil
+	}
+
+	var frames []replay.Frame
+	for _, step := range task.Steps {
+		if step.Screenshot == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(step.Screenshot)
+		if err != nil {
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(raw))
+		if err != nil {
+			log.Printf("[Task %s] Skipping step %d in replay: %v", taskID, step.Iteration, err)
+			continue
+		}
+		f := replay.Frame{Image: img, Caption: stepCaption(step)}
+		if c := step.Cursor; c != nil {
+			f.Cursor = &image.Point{X: int(math.Round(c.X)), Y: int(math.Round(c.Y))}
+		}
+		frames = append(frames, f)
+	}
+	if len(frames) == 0 {
+		return true, fmt.Errorf("task has no screenshots to replay yet")
+	}
+
+	if mjpeg {
+		return true, replay.WriteMJPEG(w, frames, opts)
+	}
+	return true, replay.WriteGIF(w, frames, opts)
+}
+
+// stepCaption describes a step in a line for the action, then its outcome
+// or the model's reasoning.
+func stepCaption(step models.Step) string {
+	caption := fmt.Sprintf("Step %d: %s", step.Iteration, describeAction(step.Action))
+	if !step.ExecutionSuccess && step.ExecutionError != "" {
+		return caption + "\nFAILED: " + step.ExecutionError
+	}
+	return caption + "\n" + step.Thought
+}
+
+// describeAction names an action with its target and value.
+func describeAction(act models.Action) string {
+	caption := string(act.Type)
+	if act.Element > 0 {
+		caption += fmt.Sprintf(" element %d", act.Element)
+	} else if isCoordinateAction(act.Type) {
+		caption += fmt.Sprintf(" at (%.0f, %.0f)", act.X, act.Y)
+	} else if act.Selector != "" {
+		caption += " " + act.Selector
+	}
+	if act.Value != "" {
+		caption += fmt.Sprintf(" %q", truncate(act.Value, 60))
+	}
+	if act.Type == models.ActionDone {
+		if act.Success {
+			caption += " (success)"
+		} else {
+			caption += " (gave up)"
+		}
+	}
+	return caption
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"log"
+	"time"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// FrameSubscription receives a running task's screencast. C holds only the
+// newest frame, so a slow viewer skips frames instead of falling behind, and
+// is closed when the task ends.
+type FrameSubscription struct {
+	C        chan models.ScreencastFrame
+	interval time.Duration
+	last     time.Time
+}
+
+// screencastOptions returns the configured live view, or false when disabled.
+func (a *Agent) screencastOptions() (browser.ScreencastOptions, bool) {
+	if a.cfg.ScreencastFPS <= 0 {
+		return browser.ScreencastOptions{}, false
+	}
+	return browser.ScreencastOptions{FPS: a.cfg.ScreencastFPS, Quality: a.cfg.ScreencastQuality}, true
+}
+
+// startScreencast streams the task's browser to its frame subscribers.
+func (a *Agent) startScreencast(taskID string, b *browser.Browser) {
+	opts, ok := a.screencastOptions()
+	if !ok {
+		return
+	}
+	err := b.StartScreencast(opts, func(f browser.Frame) {
+		a.publishFrame(models.ScreencastFrame{
+			Type:      "frame",
+			TaskID:    taskID,
+			Data:      f.Data,
+			Width:     f.Width,
+			Height:    f.Height,
+			Timestamp: f.Time,
+		})
+	})
+	if err != nil {
+		log.Printf("[Task %s] Live view disabled: %v", taskID, err)
+	}
+}
+
+// SubscribeFrames registers a viewer of a running task's screencast that
+// wants at most fps frames per second; 0 means the configured rate. The
+// latest frame is delivered right away.
+func (a *Agent) SubscribeFrames(taskID string, fps int) (*FrameSubscription, error) {
+	if _, ok := a.screencastOptions(); !ok {
+		return nil, ErrScreencastDisabled
+	}
+	if fps <= 0 || fps > a.cfg.ScreencastFPS {
+		fps = a.cfg.ScreencastFPS
+	}
+
+	// Holding frameMu while checking keeps endScreencast from missing sub
+	a.frameMu.Lock()
+	defer a.frameMu.Unlock()
+	if _, err := a.runningBrowser(taskID); err != nil {
+		return nil, err
+	}
+	sub := &FrameSubscription{
+		C:        make(chan models.ScreencastFrame, 1),
+		interval: time.Second / time.Duration(fps),
+	}
+	if f, ok := a.lastFrames[taskID]; ok {
+		sub.C <- f
+		sub.last = time.Now()
+	}
+	a.frameSubs[taskID] = append(a.frameSubs[taskID], sub)
+	return sub, nil
+}
+
+// UnsubscribeFrames removes a viewer and closes its channel.
+func (a *Agent) UnsubscribeFrames(taskID string, sub *FrameSubscription) {
+	a.frameMu.Lock()
+	defer a.frameMu.Unlock()
+	subs := a.frameSubs[taskID]
+	for i, s := range subs {
+		if s == sub {
+			a.frameSubs[taskID] = append(subs[:i], subs[i+1:]...)
+			close(sub.C)
+			return
+		}
+	}
+}
+
+// publishFrame offers a frame to every viewer whose rate allows it, replacing
+// a frame the viewer has not taken yet.
+func (a *Agent) publishFrame(f models.ScreencastFrame) {
+	a.frameMu.Lock()
+	defer a.frameMu.Unlock()
+	a.lastFrames[f.TaskID] = f
+	now := time.Now()
+	for _, sub := range a.frameSubs[f.TaskID] {
+		if now.Sub(sub.last) < sub.interval {
+			continue
+		}
+		select {
+		case <-sub.C:
+		default:
+		}
+		sub.C <- f
+		sub.last = now
+	}
+}
+
+// endScreencast closes the task's viewers once its browser is gone.
+func (a *Agent) endScreencast(taskID string) {
+	a.frameMu.Lock()
+	defer a.frameMu.Unlock()
+	for _, sub := range a.frameSubs[taskID] {
+		close(sub.C)
+	}
+	delete(a.frameSubs, taskID)
+	delete(a.lastFrames, taskID)
+}
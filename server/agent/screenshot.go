@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/anamika/zenact-web/server/llm"
+	"github.com/anamika/zenact-web/server/models"
+	"github.com/anamika/zenact-web/server/replay"
+)
+
+// maxScreenshotShrink is how many times a screenshot is made smaller after
+// the provider rejects it before the task fails.
+const maxScreenshotShrink = 2
+
+// screenshotOptions control how the screenshot is prepared for the model.
+type screenshotOptions struct {
+	maxWidth, maxHeight int
+	format              string // png or jpeg
+	quality             int
+	shrink              int // times the provider rejected the image
+}
+
+// modelScreenshot is the screenshot as sent to the model.
+type modelScreenshot struct {
+	data          []byte
+	mimeType      string
+	width, height int
+	scale         float64 // original pixels per model pixel
+}
+
+// screenshotOptions returns the configured preparation after shrink
+// rejections.
+func (a *Agent) screenshotOptions(shrink int) screenshotOptions {
+	return screenshotOptions{
+		maxWidth:  a.cfg.ScreenshotMaxWidth,
+		maxHeight: a.cfg.ScreenshotMaxHeight,
+		format:    a.cfg.ScreenshotFormat,
+		quality:   a.cfg.ScreenshotQuality,
+		shrink:    shrink,
+	}
+}
+
+// fitScreenshot scales a PNG screenshot down to fit the configured bounds and
+// encodes it. Each shrink step cuts the size to 60% and switches to JPEG at a
+// lower quality, as payload size is the usual reason for a rejection.
+func fitScreenshot(raw []byte, opts screenshotOptions) (*modelScreenshot, error) {
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decoding screenshot failed: %w", err)
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	scale := math.Min(1, math.Min(float64(opts.maxWidth)/float64(w), float64(opts.maxHeight)/float64(h)))
+	format, quality := opts.format, opts.quality
+	for range opts.shrink {
+		scale *= 0.6
+		format, quality = "jpeg", max(quality-20, 40)
+	}
+	shot := &modelScreenshot{width: w, height: h, scale: 1}
+	if scale < 1 {
+		shot.width = max(int(math.Round(float64(w)*scale)), 1)
+		shot.height = max(int(math.Round(float64(h)*scale)), 1)
+		shot.scale = float64(w) / float64(shot.width)
+		img = replay.Resize(img, shot.width, shot.height)
+	} else if format == "png" {
+		shot.data, shot.mimeType = raw, "image/png"
+		return shot, nil
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		shot.mimeType = "image/png"
+		err = png.Encode(&buf, img)
+	} else {
+		shot.mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding screenshot failed: %w", err)
+	}
+	shot.data = buf.Bytes()
+	return shot, nil
+}
+
+// toOriginal maps a point on the model's screenshot to the full-size one.
+func (s *modelScreenshot) toOriginal(p *models.Point) *models.Point {
+	if p == nil {
+		return nil
+	}
+	return &models.Point{X: p.X * s.scale, Y: p.Y * s.scale}
+}
+
+// imageErrorHints are words in a provider's 400 or 422 response that point
+// at the screenshot rather than the rest of the request.
+var imageErrorHints = []string{"image", "too large", "payload", "size", "dimension", "pixels"}
+
+// payloadRejected reports whether the provider refused the request in a way
+// a smaller screenshot may fix: a 413, or a 400 or 422 that complains about
+// the image or the request size.
+func payloadRejected(err error) (int, bool) {
+	var apiErr *llm.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestEntityTooLarge:
+		return apiErr.StatusCode, true
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		body := strings.ToLower(apiErr.Body)
+		for _, hint := range imageErrorHints {
+			if strings.Contains(body, hint) {
+				return apiErr.StatusCode, true
+			}
+		}
+	}
+	return apiErr.StatusCode, false
+}
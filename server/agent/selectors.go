@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// selectorFailures counts failed actions per selector on the page the task
+// is on.
+type selectorFailures struct {
+	page   string
+	counts map[string]int
+}
+
+// pageKey identifies a page for selector blocks: its URL without fragment.
+func pageKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// visitPage starts counting afresh when the task reaches a different page
+// and lifts the blocks made on the previous one. An unknown URL, as when
+// reading it failed, keeps the current page.
+func (a *Agent) visitPage(taskID string, f *selectorFailures, pageURL string) {
+	page := pageKey(pageURL)
+	if pageURL == "" || page == f.page {
+		return
+	}
+	f.page = page
+	f.counts = make(map[string]int)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	var kept []models.BlockedSelector
+	for _, bs := range task.BlockedSelectors {
+		if bs.Page != "" && bs.Page != page {
+			log.Printf("[Task %s] Unblocked selector %q after leaving %s", taskID, bs.Selector, bs.Page)
+			continue
+		}
+		kept = append(kept, bs)
+	}
+	task.BlockedSelectors = kept
+}
+
+// recordSelectorFailure counts a failed action that targeted a selector and
+// blocks the selector on the current page once it failed often enough.
+func (a *Agent) recordSelectorFailure(taskID string, f *selectorFailures, selector, execError string) {
+	if selector == "" || a.cfg.SelectorBlockAfter <= 0 {
+		return
+	}
+	f.counts[selector]++
+	n := f.counts[selector]
+	if n < a.cfg.SelectorBlockAfter {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task := a.tasks[taskID]
+	for _, bs := range task.BlockedSelectors {
+		if bs.Selector == selector {
+			return
+		}
+	}
+	task.BlockedSelectors = append(task.BlockedSelectors, models.BlockedSelector{
+		Selector:  selector,
+		Page:      f.page,
+		Reason:    truncate(execError, 200),
+		Failures:  n,
+		BlockedAt: time.Now(),
+	})
+	log.Printf("[Task %s] Blocked selector %q after %d failures on %s", taskID, selector, n, f.page)
+}
+
+// BlockSelector bars the model of a pending or running task from a selector
+// until it is unblocked. An automatic block of the same selector becomes
+// permanent.
+func (a *Agent) BlockSelector(taskID, selector, reason string) (*models.BlockedSelector, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return nil, fmt.Errorf("selector is required")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task, ok := a.tasks[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if task.Status != models.TaskStatusPending && task.Status != models.TaskStatusRunning {
+		return nil, ErrTaskNotRunning
+	}
+	if reason == "" {
+		reason = "blocked through the API"
+	}
+	for i := range task.BlockedSelectors {
+		if bs := &task.BlockedSelectors[i]; bs.Selector == selector {
+			bs.Page, bs.Reason = "", reason
+			copied := *bs
+			return &copied, nil
+		}
+	}
+	bs := models.BlockedSelector{Selector: selector, Reason: reason, BlockedAt: time.Now()}
+	task.BlockedSelectors = append(task.BlockedSelectors, bs)
+	return &bs, nil
+}
+
+// UnblockSelector lets the model use a blocked selector again.
+func (a *Agent) UnblockSelector(taskID, selector string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task, ok := a.tasks[taskID]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	for i, bs := range task.BlockedSelectors {
+		if bs.Selector == selector {
+			task.BlockedSelectors = append(task.BlockedSelectors[:i:i], task.BlockedSelectors[i+1:]...)
+			return nil
+		}
+	}
+	return ErrSelectorNotBlocked
+}
+
+// selectorNames lists the blocked selectors for the model.
+func selectorNames(blocked []models.BlockedSelector) []string {
+	names := make([]string, len(blocked))
+	for i, bs := range blocked {
+		names[i] = bs.Selector
+	}
+	return names
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anamika/zenact-web/server/browser"
+	"github.com/anamika/zenact-web/server/models"
+)
+
+// Upload is a file sent along with a new task.
+type Upload struct {
+	Name string
+	Body io.Reader
+}
+
+// taskDir is the working directory holding everything a task reads or produces.
+func (a *Agent) taskDir(taskID string) string {
+	return filepath.Join(a.cfg.TaskDataDir, taskID)
+}
+
+// inputDir holds the files a task may upload. The browser is restricted to it.
+func (a *Agent) inputDir(taskID string) string {
+	return filepath.Join(a.taskDir(taskID), "inputs")
+}
+
+// downloadDir receives the files the page downloads.
+func (a *Agent) downloadDir(taskID string) string {
+	return filepath.Join(a.taskDir(taskID), "downloads")
+}
+
+// captureDir receives the screenshots and PDFs saved during the task.
+func (a *Agent) captureDir(taskID string) string {
+	return filepath.Join(a.taskDir(taskID), "captures")
+}
+
+// prepareInputs stores uploaded and referenced files in the task's input
+// directory and returns the names the agent can upload them by.
+func (a *Agent) prepareInputs(taskID string, refs []models.FileRef, uploads []Upload) ([]string, error) {
+	if len(refs) == 0 && len(uploads) == 0 {
+		return nil, nil
+	}
+
+	dir := a.inputDir(taskID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create input directory: %w", err)
+	}
+
+	var names []string
+	for _, up := range uploads {
+		name, err := a.storeInput(dir, up.Name, up.Body)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	for _, ref := range refs {
+		src, err := a.resolveReference(ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		displayName := ref.Name
+		if displayName == "" {
+			displayName = filepath.Base(src)
+		}
+
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %q: %w", ref.Path, err)
+		}
+		name, err := a.storeInput(dir, displayName, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// storeInput copies one file into dir under a sanitised, unique name.
+func (a *Agent) storeInput(dir, name string, body io.Reader) (string, error) {
+	name = safeFileName(name)
+	if name == "" {
+		return "", fmt.Errorf("invalid file name")
+	}
+	name = browser.FreeFileName(dir, name)
+
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to store %q: %w", name, err)
+	}
+	defer f.Close()
+
+	limit := int64(a.cfg.MaxUploadMB) << 20
+	n, err := io.Copy(f, io.LimitReader(body, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to store %q: %w", name, err)
+	}
+	if n > limit {
+		return "", fmt.Errorf("file %q exceeds the %d MB limit", name, a.cfg.MaxUploadMB)
+	}
+	return name, nil
+}
+
+// MaxRequestBytes bounds the body of a task creation request: the uploads
+// plus room for the other form fields.
+func (a *Agent) MaxRequestBytes() int64 {
+	return int64(a.cfg.MaxUploadMB)<<20 + 1<<20
+}
+
+// resolveReference maps a referenced path to a regular file inside
+// INPUT_FILES_DIR, following symlinks before checking containment.
+func (a *Agent) resolveReference(path string) (string, error) {
+	if a.cfg.InputFilesDir == "" {
+		return "", fmt.Errorf("file references are disabled (INPUT_FILES_DIR is not set)")
+	}
+	root, err := filepath.EvalSymlinks(a.cfg.InputFilesDir)
+	if err != nil {
+		return "", fmt.Errorf("INPUT_FILES_DIR is not accessible: %w", err)
+	}
+
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(root, full)
+	}
+	full, err = filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", fmt.Errorf("file reference %q not found", path)
+	}
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file reference %q is outside INPUT_FILES_DIR", path)
+	}
+
+	info, err := os.Stat(full)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("file reference %q is not a regular file", path)
+	}
+	return full, nil
+}
+
+// safeFileName strips directories and control characters from a client-supplied name.
+func safeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
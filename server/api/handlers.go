@@ -1,11 +1,20 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/anamika/zenact-web/server/agent"
+	"github.com/anamika/zenact-web/server/browser"
 	"github.com/anamika/zenact-web/server/models"
+	"github.com/anamika/zenact-web/server/replay"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -13,18 +22,63 @@ type Handler struct {
 	agent *agent.Agent
 }
 
+// maxMultipartMemory is how much of a multipart body is buffered in memory
+// before file parts spill to disk.
+const maxMultipartMemory = 32 << 20
+
+// CreateTask accepts either a JSON body or a multipart form with a "request"
+// JSON field (or a plain "prompt" field) and file parts named "files".
 func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var uploads []agent.Upload
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, h.agent.MaxRequestBytes())
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, `{"error":"uploads exceed the size limit"}`, http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, `{"error":"invalid multipart body"}`, http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		if raw := r.FormValue("request"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req); err != nil {
+				http.Error(w, `{"error":"invalid request field"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		if prompt := r.FormValue("prompt"); prompt != "" {
+			req.Prompt = prompt
+		}
+
+		for _, fh := range r.MultipartForm.File["files"] {
+			f, err := fh.Open()
+			if err != nil {
+				http.Error(w, `{"error":"failed to read uploaded file"}`, http.StatusBadRequest)
+				return
+			}
+			defer f.Close()
+			uploads = append(uploads, agent.Upload{Name: fh.Filename, Body: f})
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
 		return
 	}
+
 	if req.Prompt == "" {
 		http.Error(w, `{"error":"prompt is required"}`, http.StatusBadRequest)
 		return
 	}
 
-	taskID := h.agent.StartTask(req.Prompt)
+	taskID, err := h.agent.StartTask(req, uploads)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -50,3 +104,192 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 }
+
+func (h *Handler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	artifacts, ok := h.agent.GetArtifacts(taskID)
+	if !ok {
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		return
+	}
+	if artifacts == nil {
+		artifacts = []models.Artifact{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(artifacts)
+}
+
+func (h *Handler) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	art, ok := h.agent.GetArtifact(taskID, chi.URLParam(r, "name"))
+	if !ok {
+		http.Error(w, `{"error":"artifact not found"}`, http.StatusNotFound)
+		return
+	}
+
+	f, err := os.Open(art.Path)
+	if err != nil {
+		http.Error(w, `{"error":"artifact file is missing"}`, http.StatusGone)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", art.MIMEType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": art.Name}))
+	http.ServeContent(w, r, art.Name, art.CreatedAt, f)
+}
+
+// GetHAR exports the network traffic recorded for a task as a HAR file.
+func (h *Handler) GetHAR(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	doc, ok := h.agent.GetHAR(taskID)
+	if !ok {
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "task-" + taskID + ".har"}))
+	json.NewEncoder(w).Encode(doc)
+}
+
+// Capture returns a screenshot or PDF of a running task's active tab. Query
+// parameters: kind (viewport, full_page, element or pdf) and, for element
+// captures, selector.
+func (h *Handler) Capture(w http.ResponseWriter, r *http.Request) {
+	kind, err := browser.ParseCaptureKind(r.URL.Query().Get("kind"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, mimeType, err := h.agent.Capture(chi.URLParam(r, "id"), kind, r.URL.Query().Get("selector"))
+	if err != nil {
+		writeCaptureError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+// SaveCapture takes a capture like Capture and keeps it as a task artifact.
+func (h *Handler) SaveCapture(w http.ResponseWriter, r *http.Request) {
+	kind, err := browser.ParseCaptureKind(r.URL.Query().Get("kind"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	art, err := h.agent.SaveCapture(chi.URLParam(r, "id"), kind, r.URL.Query().Get("selector"))
+	if err != nil {
+		writeCaptureError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(art)
+}
+
+// GetReplay renders a task's steps as an animated GIF (format=gif, the
+// default) or an MJPEG AVI video (format=mjpeg). width (default 800) and
+// delay_ms per step (default 1500) are optional.
+func (h *Handler) GetReplay(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = "gif"
+	}
+	if format != "gif" && format != "mjpeg" {
+		http.Error(w, `{"error":"format must be gif or mjpeg"}`, http.StatusBadRequest)
+		return
+	}
+	opts := replay.Options{Width: 800, Delay: 1500 * time.Millisecond}
+	if v, err := strconv.Atoi(q.Get("width")); err == nil {
+		opts.Width = min(max(v, 200), 1920)
+	}
+	if v, err := strconv.Atoi(q.Get("delay_ms")); err == nil {
+		opts.Delay = time.Duration(min(max(v, 100), 10000)) * time.Millisecond
+	}
+
+	var buf bytes.Buffer
+	found, err := h.agent.WriteReplay(&buf, taskID, format == "mjpeg", opts)
+	if !found {
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	mimeType, name := "image/gif", "task-"+taskID+".gif"
+	if format == "mjpeg" {
+		mimeType, name = "video/x-msvideo", "task-"+taskID+".avi"
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.Write(buf.Bytes())
+}
+
+// BlockSelector bars the task's model from a selector. Body:
+// {"selector": "...", "reason": "..."}; reason is optional.
+func (h *Handler) BlockSelector(w http.ResponseWriter, r *http.Request) {
+	var req models.BlockSelectorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	bs, err := h.agent.BlockSelector(chi.URLParam(r, "id"), req.Selector, req.Reason)
+	switch {
+	case errors.Is(err, agent.ErrTaskNotFound):
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		return
+	case errors.Is(err, agent.ErrTaskNotRunning):
+		http.Error(w, `{"error":"task has already finished"}`, http.StatusConflict)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(bs)
+}
+
+// UnblockSelector lifts a block; the selector is the selector query parameter.
+func (h *Handler) UnblockSelector(w http.ResponseWriter, r *http.Request) {
+	err := h.agent.UnblockSelector(chi.URLParam(r, "id"), r.URL.Query().Get("selector"))
+	switch {
+	case errors.Is(err, agent.ErrTaskNotFound):
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+	case errors.Is(err, agent.ErrSelectorNotBlocked):
+		http.Error(w, `{"error":"selector is not blocked"}`, http.StatusNotFound)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeCaptureError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, agent.ErrTaskNotFound):
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+	case errors.Is(err, agent.ErrTaskNotRunning):
+		http.Error(w, `{"error":"task is not running"}`, http.StatusConflict)
+	default:
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	}
+}
+
+// writeError writes a JSON error body with a message that may need escaping.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
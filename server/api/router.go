@@ -17,7 +17,7 @@ func NewRouter(ag *agent.Agent) *chi.Mux {
 	r.Use(middleware.RequestID)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type"},
 		AllowCredentials: true,
 	}))
@@ -32,6 +32,15 @@ func NewRouter(ag *agent.Agent) *chi.Mux {
 		r.Post("/task", h.CreateTask)
 		r.Get("/task/{id}", h.GetTask)
 		r.Get("/task/{id}/ws", h.TaskWebSocket)
+		r.Get("/task/{id}/screencast", h.ScreencastWebSocket)
+		r.Get("/task/{id}/artifacts", h.ListArtifacts)
+		r.Get("/task/{id}/artifacts/{name}", h.DownloadArtifact)
+		r.Get("/task/{id}/har", h.GetHAR)
+		r.Get("/task/{id}/replay", h.GetReplay)
+		r.Get("/task/{id}/capture", h.Capture)
+		r.Post("/task/{id}/capture", h.SaveCapture)
+		r.Post("/task/{id}/blocked-selectors", h.BlockSelector)
+		r.Delete("/task/{id}/blocked-selectors", h.UnblockSelector)
 	})
 
 	return r
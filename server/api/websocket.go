@@ -2,10 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/anamika/zenact-web/server/agent"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
@@ -73,3 +76,104 @@ func (h *Handler) TaskWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// frameAckTimeout is how long a frame may go unacknowledged before the next
+// one is sent anyway.
+const frameAckTimeout = 5 * time.Second
+
+// ScreencastWebSocket streams a running task's live view. A frame is sent
+// only after the client acknowledged the previous one with {"type":"ack"};
+// frames produced meanwhile are skipped. The optional fps query parameter
+// lowers the rate for this viewer.
+func (h *Handler) ScreencastWebSocket(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	fps, _ := strconv.Atoi(r.URL.Query().Get("fps"))
+
+	sub, err := h.agent.SubscribeFrames(taskID, fps)
+	switch {
+	case errors.Is(err, agent.ErrTaskNotFound):
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	defer h.agent.UnsubscribeFrames(taskID, sub)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Read pump — collects acknowledgements and detects client disconnect
+	acks := make(chan struct{}, 1)
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var m struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(msg, &m) == nil && m.Type == "ack" {
+				select {
+				case acks <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	// Write pump
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	var unacked <-chan time.Time // set while a sent frame awaits its ack
+	for {
+		frames := sub.C
+		if unacked != nil {
+			frames = nil
+		}
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "task ended"),
+					time.Now().Add(time.Second))
+				return
+			}
+			// Drop a stray ack so it is not taken for this frame's
+			select {
+			case <-acks:
+			default:
+			}
+			msg, _ := json.Marshal(frame)
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Printf("WebSocket write failed: %v", err)
+				return
+			}
+			unacked = time.After(frameAckTimeout)
+
+		case <-acks:
+			unacked = nil
+
+		case <-unacked:
+			unacked = nil
+
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-gone:
+			return
+		}
+	}
+}
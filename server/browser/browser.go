@@ -1,20 +1,74 @@
 package browser
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"image/png"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
 )
 
 type Browser struct {
 	allocCancel context.CancelFunc
-	ctx         context.Context
+	rootCtx     context.Context // first tab; owns the browser process
+	ctx         context.Context // active tab; guarded by mu, read through activeCtx
 	ctxCancel   context.CancelFunc
+	headless    bool
+
+	// marks, frame and worlds describe the active tab and are guarded by mu:
+	// an action abandoned for a dialog may still run beside the agent loop
+	marks     map[int]cdp.BackendNodeID
+	markSeq   int
+	frame     frameGeometry
+	worlds    map[cdp.FrameID]runtime.ExecutionContextID
+	uploadDir string
+	settle    SettleOptions
+	identity  Identity
+
+	// State updated from CDP event handlers
+	mu                 sync.Mutex
+	downloadDir        string
+	pendingDownloads   map[string]*Download
+	completedDownloads []Download
+	captureDir         string
+	captures           []Capture
+	contexts           map[cdp.FrameID]runtime.ExecutionContextID
+	dialogPolicy       DialogPolicy
+	dialogs            map[target.ID]*Dialog
+	handledDialogs     []Dialog
+	tabs               []*tab
+	active             target.ID
+	activeOpener       target.ID
+	popups             []target.ID
+	netCapture         NetworkCaptureOptions
+	netRequests        map[network.RequestID]*NetworkEntry
+	netLog             []*NetworkEntry
+	console            []ConsoleMessage
+	consoleHook        func(ConsoleMessage)
+	domainPolicies     []DomainPolicy
+	blockedRequests    []BlockedRequest
+	resourcePolicy     ResourcePolicy
+	blockedCounts      map[string]int
+	proxyAuth          *Credentials
+	authAttempts       map[fetch.RequestID]bool
+	authRequests       map[network.RequestID]fetch.RequestID // paused requests by network ID, to forget their auth attempts
+	emulation          Emulation
+	screencast         ScreencastOptions
+	onFrame            func(Frame)
+
+	dialogOpened chan struct{}
 }
 
 type ElementInfo struct {
@@ -39,7 +93,15 @@ type AccessibilityNode struct {
 	Children   []AccessibilityNode `json:"children,omitempty"`
 }
 
-func New(headless bool, width, height int) (*Browser, error) {
+// New starts a browser presenting the given network identity. Without site
+// isolation disabled, cross-origin iframes run out of process and are left
+// out of snapshots.
+func New(headless bool, width, height int, disableSiteIsolation bool, id Identity) (*Browser, error) {
+	proxyOpts, proxyAuth, err := proxyOptions(id)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.WindowSize(width, height),
 		chromedp.Flag("headless", headless),
@@ -47,22 +109,55 @@ func New(headless bool, width, height int) (*Browser, error) {
 		chromedp.Flag("no-sandbox", true),
 		chromedp.Flag("disable-dev-shm-usage", true),
 		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(desktopUserAgent),
 	)
+	if disableSiteIsolation {
+		// Keep cross-origin iframes in the page's process so DOM queries and
+		// input can reach into them
+		opts = append(opts, chromedp.Flag("disable-site-isolation-trials", true))
+	}
+	opts = append(opts, proxyOpts...)
 
 	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
 	ctx, ctxCancel := chromedp.NewContext(allocCtx)
 
+	b := &Browser{
+		allocCancel:      allocCancel,
+		rootCtx:          ctx,
+		ctx:              ctx,
+		ctxCancel:        ctxCancel,
+		headless:         headless,
+		pendingDownloads: make(map[string]*Download),
+		settle:           DefaultSettleOptions,
+		identity:         id,
+		proxyAuth:        proxyAuth,
+		dialogPolicy:     DialogAsk,
+		dialogOpened:     make(chan struct{}, 1),
+	}
+	b.listen(ctx)
+	b.listenBrowser(ctx)
+
 	if err := chromedp.Run(ctx); err != nil {
 		allocCancel()
 		return nil, fmt.Errorf("failed to start browser: %w", err)
 	}
 
-	return &Browser{
-		allocCancel: allocCancel,
-		ctx:         ctx,
-		ctxCancel:   ctxCancel,
-	}, nil
+	b.mu.Lock()
+	first := b.trackTab(chromedp.FromContext(ctx).Target.TargetID)
+	first.ctx = ctx
+	b.active = first.id
+	b.mu.Unlock()
+	b.prepareTab(ctx)
+
+	// Hold new tabs before their first navigation until onAutoAttached has
+	// prepared them
+	browserCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser)
+	if err := target.SetAutoAttach(true, true).WithFlatten(true).Do(browserCtx); err != nil {
+		allocCancel()
+		return nil, fmt.Errorf("failed to watch new tabs: %w", err)
+	}
+
+	return b, nil
 }
 
 func (b *Browser) Close() {
@@ -71,20 +166,39 @@ func (b *Browser) Close() {
 }
 
 func (b *Browser) Navigate(url string) error {
-	return chromedp.Run(b.ctx, chromedp.Navigate(url))
+	if err := b.CheckURL(url); err != nil {
+		return err
+	}
+	return chromedp.Run(b.activeCtx(), chromedp.Navigate(url))
 }
 
 func (b *Browser) Screenshot() ([]byte, error) {
 	var buf []byte
-	if err := chromedp.Run(b.ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+	var viewport []float64
+	if err := chromedp.Run(b.activeCtx(),
+		chromedp.CaptureScreenshot(&buf),
+		chromedp.Evaluate(`[window.innerWidth, window.innerHeight]`, &viewport),
+	); err != nil {
 		return nil, fmt.Errorf("screenshot failed: %w", err)
 	}
+
+	// Remember the screenshot geometry so coordinate actions can be mapped back
+	if cfg, err := png.DecodeConfig(bytes.NewReader(buf)); err == nil && len(viewport) == 2 {
+		b.mu.Lock()
+		b.frame = frameGeometry{
+			width:     cfg.Width,
+			height:    cfg.Height,
+			viewportW: viewport[0],
+			viewportH: viewport[1],
+		}
+		b.mu.Unlock()
+	}
 	return buf, nil
 }
 
 func (b *Browser) GetURL() (string, error) {
 	var url string
-	if err := chromedp.Run(b.ctx, chromedp.Location(&url)); err != nil {
+	if err := chromedp.Run(b.activeCtx(), chromedp.Location(&url)); err != nil {
 		return "", err
 	}
 	return url, nil
@@ -92,81 +206,12 @@ func (b *Browser) GetURL() (string, error) {
 
 func (b *Browser) GetTitle() (string, error) {
 	var title string
-	if err := chromedp.Run(b.ctx, chromedp.Title(&title)); err != nil {
+	if err := chromedp.Run(b.activeCtx(), chromedp.Title(&title)); err != nil {
 		return "", err
 	}
 	return title, nil
 }
 
-func (b *Browser) GetFullDOM() (string, error) {
-	var domContent string
-
-	script := `
-		(async () => {
-			try {
-				let html = '<!DOCTYPE html><html>';
-				
-				function serializeNode(node, depth = 0) {
-					if (depth > 10) return '';
-					let result = '';
-					
-					if (node.nodeType === Node.ELEMENT_NODE) {
-						let attrs = '';
-						if (node.id) attrs += ' id="' + node.id.replace(/"/g, '&quot;') + '"';
-						if (node.className && typeof node.className === 'string') {
-							const classes = node.className.split(' ').filter(Boolean).join(' ');
-							if (classes) attrs += ' class="' + classes.replace(/"/g, '&quot;') + '"';
-						}
-						
-						let role = node.getAttribute('role') || '';
-						let text = (node.textContent || '').trim().substring(0, 100);
-						let placeholder = node.getAttribute('placeholder') || '';
-						let typeAttr = node.getAttribute('type') || '';
-						let name = node.getAttribute('name') || '';
-						
-						result += '<div class="__browser_elem" data-role="' + role + '" data-text="' + text.replace(/"/g, '&quot;') + 
-						           '" data-placeholder="' + placeholder.replace(/"/g, '&quot;') + 
-						           '" data-type="' + typeAttr + 
-						           '" data-name="' + name.replace(/"/g, '&quot;') + '">';
-						result += '<' + node.tagName.toLowerCase() + attrs + '>';
-						
-						for (let child of node.childNodes) {
-							result += serializeNode(child, depth + 1);
-						}
-						
-						result += '</' + node.tagName.toLowerCase() + '>';
-						result += '</div>';
-					} else if (node.nodeType === Node.TEXT_NODE) {
-						let text = node.textContent.trim();
-						if (text) result += '<span class="__browser_text">' + text.replace(/</g, '&lt;').replace(/>/g, '&gt;') + '</span>';
-					}
-					
-					return result;
-				}
-				
-				const doc = document.documentElement;
-				html += serializeNode(doc);
-				html += '</html>';
-				
-				return html;
-			} catch (e) {
-				return 'ERROR: ' + e.toString();
-			}
-		})();
-	`
-
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &domContent))
-	if err != nil {
-		return "", err
-	}
-
-	if strings.Contains(domContent, "ERROR:") {
-		return "", fmt.Errorf("DOM extraction failed: %s", domContent)
-	}
-
-	return domContent, nil
-}
-
 func (b *Browser) GetAccessibilityTree() (string, error) {
 	var axTree string
 
@@ -193,6 +238,27 @@ func (b *Browser) GetAccessibilityTree() (string, error) {
 						axNode.value = node.value || '';
 					}
 					
+					if (node.tagName === 'SELECT') {
+						const opts = Array.from(node.options);
+						axNode.properties['options'] = opts.slice(0, 25).map(o => o.label.trim()).join(' | ') + (opts.length > 25 ? ' | ...' : '');
+						const selected = node.options[node.selectedIndex];
+						axNode.value = selected ? selected.label.trim() : '';
+						if (node.multiple) axNode.properties['multiple'] = 'true';
+					}
+					
+					if (node.tagName === 'INPUT' && (node.type === 'checkbox' || node.type === 'radio')) {
+						axNode.properties['checked'] = node.checked ? 'true' : 'false';
+					} else if (node.getAttribute('aria-checked') !== null) {
+						axNode.properties['checked'] = node.getAttribute('aria-checked');
+					}
+					
+					if (node.tagName === 'INPUT' && node.type && node.type !== 'text') {
+						axNode.properties['input_type'] = node.type;
+					}
+					if (node.disabled) {
+						axNode.properties['disabled'] = 'true';
+					}
+					
 					if (node.tagName === 'BUTTON' || node.getAttribute('role') === 'button') {
 						axNode.properties['interactive'] = 'true';
 					}
@@ -248,10 +314,21 @@ func (b *Browser) GetAccessibilityTree() (string, error) {
 				}
 				
 				const interactive = ['button', 'link', 'textbox', 'checkbox', 'radio', 'combobox', 'heading', 'menuitem'];
-				const elements = Array.from(document.querySelectorAll('*'));
+				// Elements in document order, descending into open shadow roots.
+				// Each carries the "host >>> " prefix needed to select it.
+				const elements = [];
+				(function collect(root, prefix) {
+					for (const el of root.querySelectorAll('*')) {
+						elements.push([el, prefix]);
+						if (el.shadowRoot) {
+							const host = el.tagName.toLowerCase() + (el.id ? '#' + el.id : '');
+							collect(el.shadowRoot, prefix + host + ' >>> ');
+						}
+					}
+				})(document, '');
 				
 				const axNodes = [];
-				for (const el of elements) {
+				for (const [el, prefix] of elements) {
 					if (el.offsetParent === null) continue;
 					const rect = el.getBoundingClientRect();
 					if (rect.width < 5 || rect.height < 5) continue;
@@ -267,7 +344,7 @@ func (b *Browser) GetAccessibilityTree() (string, error) {
 							const cls = el.className.split(' ').filter(Boolean)[0];
 							if (cls) selector += '.' + cls;
 						}
-						node.selector = selector;
+						node.selector = prefix + selector;
 						axNodes.push(node);
 					}
 				}
@@ -279,7 +356,45 @@ func (b *Browser) GetAccessibilityTree() (string, error) {
 		})();
 	`
 
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &axTree))
+	err := chromedp.Run(b.activeCtx(), chromedp.ActionFunc(func(ctx context.Context) error {
+		frames, err := b.frameContexts(ctx)
+		if err != nil {
+			return err
+		}
+		if err := b.evalInFrame(ctx, &frames[0], script, &axTree); err != nil {
+			return err
+		}
+		if len(frames) == 1 || strings.Contains(axTree, "ERROR:") {
+			return nil
+		}
+
+		// Append the nodes of child frames with frame-qualified selectors
+		var nodes []map[string]interface{}
+		if err := json.Unmarshal([]byte(axTree), &nodes); err != nil {
+			return nil
+		}
+		for i := range frames[1:] {
+			fc := &frames[i+1]
+			var frameTree string
+			var frameNodes []map[string]interface{}
+			if err := b.evalInFrame(ctx, fc, script, &frameTree); err != nil {
+				continue
+			}
+			if err := json.Unmarshal([]byte(frameTree), &frameNodes); err != nil {
+				continue
+			}
+			for _, node := range frameNodes {
+				node["selector"] = fmt.Sprintf("%s %s %v", fc.selector, piercer, node["selector"])
+				nodes = append(nodes, node)
+			}
+		}
+		merged, err := json.Marshal(nodes)
+		if err != nil {
+			return err
+		}
+		axTree = string(merged)
+		return nil
+	}))
 	if err != nil {
 		return "", err
 	}
@@ -313,7 +428,7 @@ func (b *Browser) FindElementByText(text string) (string, error) {
 		})();
 	`, strings.ReplaceAll(text, `"`, `\"`))
 
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &selector))
+	err := chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, &selector))
 	if err != nil {
 		return "", err
 	}
@@ -350,7 +465,7 @@ func (b *Browser) FindElementsByText(text string) ([]string, error) {
 	`, strings.ReplaceAll(text, `"`, `\"`))
 
 	var jsonResult string
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &jsonResult))
+	err := chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, &jsonResult))
 	if err != nil {
 		return nil, err
 	}
@@ -363,7 +478,11 @@ func (b *Browser) FindElementsByText(text string) ([]string, error) {
 }
 
 func (b *Browser) Click(selector string) error {
-	timeoutCtx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	if strings.Contains(selector, piercer) {
+		return b.clickTarget(Target{Selector: selector})
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
 	defer cancel()
 
 	return chromedp.Run(timeoutCtx,
@@ -378,7 +497,11 @@ func (b *Browser) ClickByID(id string) error {
 }
 
 func (b *Browser) Type(selector, text string) error {
-	timeoutCtx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	if strings.Contains(selector, piercer) {
+		return b.typeTarget(Target{Selector: selector}, text)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
 	defer cancel()
 
 	return chromedp.Run(timeoutCtx,
@@ -399,7 +522,7 @@ func (b *Browser) Scroll(direction string) error {
 		pixels = -500
 	}
 	script := fmt.Sprintf("window.scrollBy(0, %d)", pixels)
-	return chromedp.Run(b.ctx, chromedp.Evaluate(script, nil))
+	return chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, nil))
 }
 
 func (b *Browser) ScrollToElement(selector string) error {
@@ -415,7 +538,7 @@ func (b *Browser) ScrollToElement(selector string) error {
 	`, strings.ReplaceAll(selector, `'`, `\\'`))
 
 	var result string
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &result))
+	err := chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, &result))
 	if err != nil {
 		return err
 	}
@@ -427,16 +550,14 @@ func (b *Browser) ScrollToElement(selector string) error {
 	return nil
 }
 
+// WaitForLoad waits for the page to settle, see WaitSettled.
 func (b *Browser) WaitForLoad() error {
-	time.Sleep(2 * time.Second)
+	if !b.WaitSettled() {
+		return fmt.Errorf("page did not settle within %s", b.settle.Max)
+	}
 	return nil
 }
 
-func (b *Browser) PressKey(key string) error {
-	script := fmt.Sprintf("document.activeElement.dispatchEvent(new KeyboardEvent('keydown', { key: '%s', code: '%s', bubbles: true }));", key, key)
-	return chromedp.Run(b.ctx, chromedp.Evaluate(script, nil))
-}
-
 func (b *Browser) ClickAt(selector string, x, y float64) error {
 	script := fmt.Sprintf(`
 		(function() {
@@ -452,7 +573,7 @@ func (b *Browser) ClickAt(selector string, x, y float64) error {
 	`, strings.ReplaceAll(selector, `'`, `\\'`), x, y)
 
 	var result string
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &result))
+	err := chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, &result))
 	if err != nil {
 		return err
 	}
@@ -466,7 +587,7 @@ func (b *Browser) ClickAt(selector string, x, y float64) error {
 
 func (b *Browser) ExecuteScript(script string) (string, error) {
 	var result string
-	err := chromedp.Run(b.ctx, chromedp.Evaluate(script, &result))
+	err := chromedp.Run(b.activeCtx(), chromedp.Evaluate(script, &result))
 	if err != nil {
 		return "", err
 	}
@@ -481,112 +602,77 @@ func (b *Browser) GetBase64Screenshot() (string, error) {
 	return base64.StdEncoding.EncodeToString(buf), nil
 }
 
-func (b *Browser) Hold(selector string, duration time.Duration) error {
-	ms := strconv.Itoa(int(duration.Milliseconds()))
-	script := fmt.Sprintf(`
-		(function() {
-			var el = document.querySelector('%s');
-			if (!el) return 'Element not found';
-			var event = new MouseEvent('mousedown', { bubbles: true, cancelable: true, clientX: 0, clientY: 0 });
-			el.dispatchEvent(event);
-			setTimeout(function() {
-				var up = new MouseEvent('mouseup', { bubbles: true, cancelable: true, clientX: 0, clientY: 0 });
-				el.dispatchEvent(up);
-			}, %s);
-			return 'OK';
-		})();
-	`, strings.ReplaceAll(selector, `'`, `\\'`), ms)
-	_, err := b.ExecuteScript(script)
-	return err
+// Hold presses the left mouse button on an element and releases it after duration.
+func (b *Browser) Hold(t Target, duration time.Duration) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second+duration)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := b.resolve(ctx, t)
+		if err != nil {
+			return err
+		}
+		x, y, err := nodeCenter(ctx, id)
+		if err != nil {
+			return fmt.Errorf("hold on %s: %w", t, err)
+		}
+		return mouseHold(ctx, x, y, duration)
+	}))
 }
 
-func (b *Browser) Drag(sourceSelector string, target string) error {
-	var script string
+// Drag drags the source element to a target, which is "up" or "down" (300px),
+// an "x,y" pixel offset from the source centre, or the number or a selector of
+// the drop target.
+func (b *Browser) Drag(source Target, target string) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 15*time.Second)
+	defer cancel()
 
-	if target == "down" || target == "up" {
-		offsetY := 300
-		if target == "up" {
-			offsetY = -300
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		src, err := b.resolve(ctx, source)
+		if err != nil {
+			return err
 		}
-		script = fmt.Sprintf(`
-			(function() {
-				var el = document.querySelector('%s');
-				if (!el) return 'Element not found';
-				var rect = el.getBoundingClientRect();
-				var startX = rect.left + rect.width / 2;
-				var startY = rect.top + rect.height / 2;
-				var endX = startX;
-				var endY = startY + %d;
-				
-				var down = new MouseEvent('mousedown', { bubbles: true, cancelable: true, clientX: startX, clientY: startY });
-				el.dispatchEvent(down);
-				
-				var move = new MouseEvent('mousemove', { bubbles: true, cancelable: true, clientX: endX, clientY: endY });
-				window.dispatchEvent(move);
-				
-				setTimeout(function() {
-					var up = new MouseEvent('mouseup', { bubbles: true, cancelable: true, clientX: endX, clientY: endY });
-					window.dispatchEvent(up);
-				}, 100);
-				return 'OK';
-			})();
-		`, strings.ReplaceAll(sourceSelector, `'`, `\\'`), offsetY)
-	} else if strings.Contains(target, ",") {
-		parts := strings.Split(target, ",")
-		if len(parts) == 2 {
-			x, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
-			y, _ := strconv.Atoi(strings.TrimSpace(parts[1]))
-			script = fmt.Sprintf(`
-				(function() {
-					var el = document.querySelector('%s');
-					if (!el) return 'Element not found';
-					var rect = el.getBoundingClientRect();
-					var startX = rect.left + rect.width / 2;
-					var startY = rect.top + rect.height / 2;
-					
-					var down = new MouseEvent('mousedown', { bubbles: true, cancelable: true, clientX: startX, clientY: startY });
-					el.dispatchEvent(down);
-					
-					var move = new MouseEvent('mousemove', { bubbles: true, cancelable: true, clientX: startX + %d, clientY: startY + %d });
-					window.dispatchEvent(move);
-					
-					setTimeout(function() {
-						var up = new MouseEvent('mouseup', { bubbles: true, cancelable: true, clientX: startX + %d, clientY: startY + %d });
-						window.dispatchEvent(up);
-					}, 100);
-					return 'OK';
-				})();
-			`, strings.ReplaceAll(sourceSelector, `'`, `\\'`), x, y, x, y)
+		fromX, fromY, err := nodeCenter(ctx, src)
+		if err != nil {
+			return fmt.Errorf("drag source %s: %w", source, err)
+		}
+
+		var toX, toY float64
+		switch {
+		case target == "down" || target == "up":
+			offsetY := 300.0
+			if target == "up" {
+				offsetY = -300
+			}
+			toX, toY = fromX, fromY+offsetY
+
+		case strings.Contains(target, ","):
+			parts := strings.Split(target, ",")
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid drag offset %q, expected \"x,y\"", target)
+			}
+			dx, errX := strconv.Atoi(strings.TrimSpace(parts[0]))
+			dy, errY := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if errX != nil || errY != nil {
+				return fmt.Errorf("invalid drag offset %q, expected integer \"x,y\"", target)
+			}
+			toX, toY = fromX+float64(dx), fromY+float64(dy)
+
+		default:
+			// A bare number is an element number, never a valid selector
+			dropTarget := Target{Selector: target}
+			if n, err := strconv.Atoi(target); err == nil {
+				dropTarget = Target{Element: n}
+			}
+			dst, err := b.resolve(ctx, dropTarget)
+			if err != nil {
+				return err
+			}
+			if toX, toY, err = quadCenter(ctx, dst); err != nil {
+				return fmt.Errorf("drag target %s: %w", target, err)
+			}
 		}
-	} else {
-		script = fmt.Sprintf(`
-			(function() {
-				var source = document.querySelector('%s');
-				var target = document.querySelector('%s');
-				if (!source || !target) return 'Element not found';
-				
-				var sourceRect = source.getBoundingClientRect();
-				var targetRect = target.getBoundingClientRect();
-				var startX = sourceRect.left + sourceRect.width / 2;
-				var startY = sourceRect.top + sourceRect.height / 2;
-				var endX = targetRect.left + targetRect.width / 2;
-				var endY = targetRect.top + targetRect.height / 2;
-				
-				var down = new MouseEvent('mousedown', { bubbles: true, cancelable: true, clientX: startX, clientY: startY });
-				source.dispatchEvent(down);
-				
-				var move = new MouseEvent('mousemove', { bubbles: true, cancelable: true, clientX: endX, clientY: endY });
-				window.dispatchEvent(move);
-				
-				setTimeout(function() {
-					var up = new MouseEvent('mouseup', { bubbles: true, cancelable: true, clientX: endX, clientY: endY });
-					window.dispatchEvent(up);
-				}, 100);
-				return 'OK';
-			})();
-		`, strings.ReplaceAll(sourceSelector, `'`, `\\'`), strings.ReplaceAll(target, `'`, `\\'`))
-	}
 
-	_, err := b.ExecuteScript(script)
-	return err
+		return mouseDrag(ctx, fromX, fromY, toX, toY)
+	}))
 }
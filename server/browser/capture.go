@@ -0,0 +1,218 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// CaptureKind selects what a capture contains.
+type CaptureKind string
+
+const (
+	CaptureViewport CaptureKind = "viewport"
+	CaptureFullPage CaptureKind = "full_page"
+	CaptureElement  CaptureKind = "element"
+	CapturePDF      CaptureKind = "pdf"
+)
+
+// ParseCaptureKind validates a capture kind; empty means the viewport.
+func ParseCaptureKind(s string) (CaptureKind, error) {
+	switch k := CaptureKind(s); k {
+	case "":
+		return CaptureViewport, nil
+	case CaptureViewport, CaptureFullPage, CaptureElement, CapturePDF:
+		return k, nil
+	}
+	return "", fmt.Errorf("unknown capture kind %q (use viewport, full_page, element or pdf)", s)
+}
+
+// Capture is a screenshot or PDF saved into the capture directory.
+type Capture struct {
+	Kind     CaptureKind
+	Name     string
+	Path     string
+	Size     int64
+	MIMEType string
+	URL      string // page the capture was taken of
+}
+
+// SetCaptureDir sets where SaveCapture stores files.
+func (b *Browser) SetCaptureDir(dir string) {
+	b.mu.Lock()
+	b.captureDir = dir
+	b.mu.Unlock()
+}
+
+// activeCtx returns the active tab's context. It may be called while the
+// agent loop is switching tabs.
+func (b *Browser) activeCtx() context.Context {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ctx
+}
+
+// CaptureBytes takes a capture of the active tab and returns it with its MIME
+// type. It does not change the geometry used to map screenshot coordinates,
+// so it is safe to call while the agent is running. Element captures need a
+// selector when called from outside the agent loop.
+func (b *Browser) CaptureBytes(kind CaptureKind, t Target) ([]byte, string, error) {
+	if b.PendingDialog() != nil {
+		return nil, "", fmt.Errorf("a JavaScript dialog is open; the page cannot be captured until it is handled")
+	}
+	if kind == CapturePDF && !b.headless {
+		return nil, "", fmt.Errorf("PDF printing requires BROWSER_HEADLESS=true")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 30*time.Second)
+	defer cancel()
+
+	var buf []byte
+	var action chromedp.Action
+	mimeType := "image/png"
+	switch kind {
+	case CaptureViewport:
+		action = chromedp.CaptureScreenshot(&buf)
+	case CaptureFullPage:
+		action = chromedp.FullScreenshot(&buf, 100)
+	case CaptureElement:
+		action = chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, err = b.captureElement(ctx, t)
+			return err
+		})
+	case CapturePDF:
+		mimeType = "application/pdf"
+		action = chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, _, err = page.PrintToPDF().WithPrintBackground(true).Do(ctx)
+			return err
+		})
+	default:
+		return nil, "", fmt.Errorf("unknown capture kind %q", kind)
+	}
+	if err := chromedp.Run(timeoutCtx, action); err != nil {
+		return nil, "", fmt.Errorf("%s capture failed: %w", kind, err)
+	}
+	return buf, mimeType, nil
+}
+
+// captureElement screenshots the target's boxes, including the parts scrolled
+// out of view. The page is not scrolled.
+func (b *Browser) captureElement(ctx context.Context, t Target) ([]byte, error) {
+	id, err := b.resolve(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	quads, err := dom.GetContentQuads().WithBackendNodeID(id).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", t, err)
+	}
+	if len(quads) == 0 {
+		return nil, fmt.Errorf("%s is not rendered", t)
+	}
+	_, _, _, _, visual, _, err := page.GetLayoutMetrics().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quads are in viewport pixels; the clip is in document pixels
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, q := range quads {
+		for i := 0; i+1 < len(q); i += 2 {
+			minX, maxX = math.Min(minX, q[i]), math.Max(maxX, q[i])
+			minY, maxY = math.Min(minY, q[i+1]), math.Max(maxY, q[i+1])
+		}
+	}
+	x, y := math.Floor(minX+visual.PageX), math.Floor(minY+visual.PageY)
+	w, h := math.Ceil(maxX+visual.PageX-x), math.Ceil(maxY+visual.PageY-y)
+	if w < 1 || h < 1 {
+		return nil, fmt.Errorf("%s has no visible area", t)
+	}
+
+	return page.CaptureScreenshot().
+		WithFormat(page.CaptureScreenshotFormatPng).
+		WithCaptureBeyondViewport(true).
+		WithFromSurface(true).
+		WithClip(&page.Viewport{X: x, Y: y, Width: w, Height: h, Scale: 1}).
+		Do(ctx)
+}
+
+// SaveCapture takes a capture and stores it in the capture directory as
+// name, numbered if taken. The file is reported by Captures.
+func (b *Browser) SaveCapture(kind CaptureKind, t Target, name string) (Capture, error) {
+	c, err := b.WriteCapture(kind, t, name)
+	if err != nil {
+		return Capture{}, err
+	}
+	b.mu.Lock()
+	b.captures = append(b.captures, c)
+	b.mu.Unlock()
+	return c, nil
+}
+
+// WriteCapture is SaveCapture for callers that record the file themselves;
+// it is not reported by Captures.
+func (b *Browser) WriteCapture(kind CaptureKind, t Target, name string) (Capture, error) {
+	b.mu.Lock()
+	dir := b.captureDir
+	b.mu.Unlock()
+	if dir == "" {
+		return Capture{}, fmt.Errorf("captures are not enabled")
+	}
+
+	data, mimeType, err := b.CaptureBytes(kind, t)
+	if err != nil {
+		return Capture{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Capture{}, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	name = FreeFileName(dir, name)
+	path := filepath.Join(dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return Capture{}, fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return Capture{}, fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	c := Capture{
+		Kind:     kind,
+		Name:     name,
+		Path:     path,
+		Size:     int64(len(data)),
+		MIMEType: mimeType,
+	}
+	for _, tb := range b.tabs {
+		if tb.id == b.active {
+			c.URL = tb.url
+		}
+	}
+	return c, nil
+}
+
+// Captures returns the captures saved since the previous call.
+func (b *Browser) Captures() []Capture {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	saved := b.captures
+	b.captures = nil
+	return saved
+}
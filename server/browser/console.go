@@ -0,0 +1,112 @@
+package browser
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+const (
+	// Console output kept between two drains; the oldest messages go first
+	maxConsoleMessages = 200
+	maxConsoleText     = 500
+)
+
+// ConsoleMessage is a console API call or an uncaught exception on a page.
+type ConsoleMessage struct {
+	Level     string // log, info, warning, error, debug, ...
+	Text      string
+	URL       string
+	Line      int64 // 1-based, 0 if unknown
+	Exception bool
+	Time      time.Time
+}
+
+// OnConsole sets a function called for every console message as it arrives.
+// It runs on the event goroutine and must not block.
+func (b *Browser) OnConsole(fn func(ConsoleMessage)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consoleHook = fn
+}
+
+// ConsoleMessages returns the console output since the previous call.
+func (b *Browser) ConsoleMessages() []ConsoleMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msgs := b.console
+	b.console = nil
+	return msgs
+}
+
+func (b *Browser) onConsoleAPICalled(ev *runtime.EventConsoleAPICalled) {
+	parts := make([]string, 0, len(ev.Args))
+	for _, arg := range ev.Args {
+		parts = append(parts, remoteObjectText(arg))
+	}
+	m := ConsoleMessage{
+		Level: string(ev.Type),
+		Text:  strings.Join(parts, " "),
+		Time:  time.Now(),
+	}
+	if ev.StackTrace != nil && len(ev.StackTrace.CallFrames) > 0 {
+		frame := ev.StackTrace.CallFrames[0]
+		m.URL, m.Line = frame.URL, frame.LineNumber+1
+	}
+	b.addConsole(m)
+}
+
+func (b *Browser) onExceptionThrown(ev *runtime.EventExceptionThrown) {
+	d := ev.ExceptionDetails
+	m := ConsoleMessage{
+		Level:     string(runtime.APITypeError),
+		Text:      d.Text,
+		URL:       d.URL,
+		Line:      d.LineNumber + 1,
+		Exception: true,
+		Time:      time.Now(),
+	}
+	// The description carries the message and stack, e.g. "TypeError: x is undefined\n    at ..."
+	if d.Exception != nil && d.Exception.Description != "" {
+		m.Text = d.Exception.Description
+	}
+	b.addConsole(m)
+}
+
+func (b *Browser) addConsole(m ConsoleMessage) {
+	if len(m.Text) > maxConsoleText {
+		m.Text = m.Text[:maxConsoleText] + "..."
+	}
+
+	b.mu.Lock()
+	if len(b.console) >= maxConsoleMessages {
+		b.console = b.console[1:]
+	}
+	b.console = append(b.console, m)
+	hook := b.consoleHook
+	b.mu.Unlock()
+
+	if hook != nil {
+		hook(m)
+	}
+}
+
+// remoteObjectText renders a console argument the way DevTools prints it.
+func remoteObjectText(o *runtime.RemoteObject) string {
+	if len(o.Value) > 0 {
+		var s string
+		if err := json.Unmarshal(o.Value, &s); err == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.UnserializableValue != "" {
+		return string(o.UnserializableValue)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return string(o.Type)
+}
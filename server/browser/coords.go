@@ -0,0 +1,103 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// frameGeometry relates the pixels of the screenshot the model last saw to the
+// CSS pixels of the viewport. They differ on high-DPI displays and whenever the
+// screenshot is resized before being sent.
+type frameGeometry struct {
+	width, height        int
+	viewportW, viewportH float64
+}
+
+// toViewport maps a point on the screenshot to viewport coordinates.
+func (g frameGeometry) toViewport(x, y float64) (float64, float64, error) {
+	if g.width == 0 || g.height == 0 || g.viewportW == 0 || g.viewportH == 0 {
+		return x, y, nil
+	}
+	if x < 0 || y < 0 || x > float64(g.width) || y > float64(g.height) {
+		return 0, 0, fmt.Errorf("point (%.0f, %.0f) is outside the %dx%d screenshot", x, y, g.width, g.height)
+	}
+	return x * g.viewportW / float64(g.width), y * g.viewportH / float64(g.height), nil
+}
+
+// toScreenshot maps a viewport point to screenshot pixels.
+func (g frameGeometry) toScreenshot(x, y float64) (float64, float64) {
+	if g.width == 0 || g.height == 0 || g.viewportW == 0 || g.viewportH == 0 {
+		return x, y
+	}
+	return x * float64(g.width) / g.viewportW, y * float64(g.height) / g.viewportH
+}
+
+// ScreenshotPoint maps a point in viewport CSS pixels, such as the centre of
+// a marked element's bounds, to pixels of the last screenshot.
+func (b *Browser) ScreenshotPoint(x, y float64) (float64, float64) {
+	return b.geometry().toScreenshot(x, y)
+}
+
+// SetScreenshotSize records that the model was shown the last screenshot
+// resized to width x height, so its coordinates are mapped from that size.
+func (b *Browser) SetScreenshotSize(width, height int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.frame.width, b.frame.height = width, height
+}
+
+// geometry returns the last screenshot's geometry.
+func (b *Browser) geometry() frameGeometry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.frame
+}
+
+func (b *Browser) runAtPoint(x, y float64, fn func(ctx context.Context, vx, vy float64) error) error {
+	vx, vy, err := b.geometry().toViewport(x, y)
+	if err != nil {
+		return err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return fn(ctx, vx, vy)
+	}))
+}
+
+// ClickPoint clicks at a point given in screenshot pixels.
+func (b *Browser) ClickPoint(x, y float64) error {
+	return b.runAtPoint(x, y, func(ctx context.Context, vx, vy float64) error {
+		return mouseClick(ctx, vx, vy, 1)
+	})
+}
+
+// DoubleClickPoint double-clicks at a point given in screenshot pixels.
+func (b *Browser) DoubleClickPoint(x, y float64) error {
+	return b.runAtPoint(x, y, func(ctx context.Context, vx, vy float64) error {
+		return mouseClick(ctx, vx, vy, 2)
+	})
+}
+
+// MovePointer hovers the mouse over a point given in screenshot pixels.
+func (b *Browser) MovePointer(x, y float64) error {
+	return b.runAtPoint(x, y, func(ctx context.Context, vx, vy float64) error {
+		return mouseMove(ctx, vx, vy)
+	})
+}
+
+// DragPoints drags with the left button held from one screenshot point to another.
+func (b *Browser) DragPoints(fromX, fromY, toX, toY float64) error {
+	tx, ty, err := b.geometry().toViewport(toX, toY)
+	if err != nil {
+		return err
+	}
+	return b.runAtPoint(fromX, fromY, func(ctx context.Context, vx, vy float64) error {
+		return mouseDrag(ctx, vx, vy, tx, ty)
+	})
+}
@@ -0,0 +1,148 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// DialogPolicy decides what happens to alert, confirm, prompt and
+// beforeunload dialogs when they open.
+type DialogPolicy string
+
+const (
+	DialogAsk     DialogPolicy = "ask" // leave open for the agent to handle
+	DialogAccept  DialogPolicy = "accept"
+	DialogDismiss DialogPolicy = "dismiss"
+)
+
+// ParseDialogPolicy validates a policy name; empty means ask.
+func ParseDialogPolicy(s string) (DialogPolicy, error) {
+	switch p := DialogPolicy(s); p {
+	case "":
+		return DialogAsk, nil
+	case DialogAsk, DialogAccept, DialogDismiss:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown dialog policy %q (use ask, accept or dismiss)", s)
+}
+
+// Dialog is a JavaScript dialog opened by a page.
+type Dialog struct {
+	Type          string `json:"type"` // alert, confirm, prompt or beforeunload
+	Message       string `json:"message"`
+	DefaultPrompt string `json:"default_prompt,omitempty"`
+	URL           string `json:"url"`
+	Result        string `json:"result,omitempty"` // "accepted" or "dismissed" once handled by policy
+}
+
+// SetDialogPolicy sets how dialogs opened from now on are handled.
+func (b *Browser) SetDialogPolicy(p DialogPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dialogPolicy = p
+}
+
+// onDialogOpening handles a dialog according to the policy or keeps it
+// pending. While a dialog is open the page's script is paused, so any CDP
+// command that needs it blocks until the dialog is closed.
+func (b *Browser) onDialogOpening(ctx context.Context, ev *page.EventJavascriptDialogOpening) {
+	d := Dialog{
+		Type:          string(ev.Type),
+		Message:       ev.Message,
+		DefaultPrompt: ev.DefaultPrompt,
+		URL:           ev.URL,
+	}
+
+	b.mu.Lock()
+	policy := b.dialogPolicy
+	if policy == DialogAsk || policy == "" {
+		if b.dialogs == nil {
+			b.dialogs = make(map[target.ID]*Dialog)
+		}
+		b.dialogs[chromedp.FromContext(ctx).Target.TargetID] = &d
+		b.mu.Unlock()
+		select {
+		case b.dialogOpened <- struct{}{}:
+		default:
+		}
+		return
+	}
+	accept := policy == DialogAccept
+	if accept {
+		d.Result = "accepted"
+	} else {
+		d.Result = "dismissed"
+	}
+	b.handledDialogs = append(b.handledDialogs, d)
+	b.mu.Unlock()
+
+	// Handlers must not issue commands synchronously
+	go func() {
+		if err := chromedp.Run(ctx, page.HandleJavaScriptDialog(accept).WithPromptText(ev.DefaultPrompt)); err != nil {
+			log.Printf("WARNING: could not close %s dialog: %v", ev.Type, err)
+		}
+	}()
+}
+
+func (b *Browser) onDialogClosed(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.dialogs, chromedp.FromContext(ctx).Target.TargetID)
+}
+
+// PendingDialog returns the dialog waiting on the active tab, or nil.
+func (b *Browser) PendingDialog() *Dialog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, ok := b.dialogs[b.active]
+	if !ok {
+		return nil
+	}
+	copied := *d
+	return &copied
+}
+
+// HandledDialogs returns the dialogs closed by the policy since the previous call.
+func (b *Browser) HandledDialogs() []Dialog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	handled := b.handledDialogs
+	b.handledDialogs = nil
+	return handled
+}
+
+// DialogOpened is signalled when a dialog opens that waits for the agent.
+func (b *Browser) DialogOpened() <-chan struct{} {
+	return b.dialogOpened
+}
+
+// HandleDialog accepts or dismisses the dialog on the active tab. promptText
+// is entered into prompt() dialogs when accepting.
+func (b *Browser) HandleDialog(accept bool, promptText string) error {
+	d := b.PendingDialog()
+	if d == nil {
+		return fmt.Errorf("no dialog is open")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	action := page.HandleJavaScriptDialog(accept)
+	if accept && d.Type == string(page.DialogTypePrompt) {
+		action = action.WithPromptText(promptText)
+	}
+	if err := chromedp.Run(timeoutCtx, action); err != nil {
+		return fmt.Errorf("closing %s dialog failed: %w", d.Type, err)
+	}
+
+	b.mu.Lock()
+	delete(b.dialogs, b.active)
+	b.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,341 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Kinds of distilled DOM nodes.
+const (
+	NodeInteractive = "interactive"
+	NodeHeading     = "heading"
+	NodeForm        = "form"
+	NodeText        = "text"
+)
+
+// DOMNode is one entry of the distilled page: an interactive element, a
+// heading, a form or a block of visible text.
+type DOMNode struct {
+	Kind     string            `json:"kind"`
+	Tag      string            `json:"tag"`
+	Selector string            `json:"selector"`
+	Text     string            `json:"text,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Mark     int               `json:"mark,omitempty"`     // element number, if currently marked
+	Distance int               `json:"distance,omitempty"` // CSS pixels outside the viewport; negative above it
+	Frame    string            `json:"frame,omitempty"`
+	Order    int               `json:"-"` // position in the document
+}
+
+// distillScript lists the meaningful nodes of one frame in document order,
+// including open shadow roots, each with a selector that finds it again.
+const distillScript = `function(limit) {
+	const interactive = 'a[href], button, input:not([type="hidden"]), select, textarea, summary, [contenteditable=""], [contenteditable="true"], [onclick], ' +
+		'[role="button"], [role="link"], [role="checkbox"], [role="radio"], [role="tab"], [role="menuitem"], [role="option"], [role="switch"], [role="combobox"], [role="textbox"], [role="slider"]';
+	const skip = new Set(['SCRIPT', 'STYLE', 'NOSCRIPT', 'TEMPLATE', 'SVG', 'PATH', 'HEAD', 'META', 'LINK']);
+	const keep = ['id', 'name', 'type', 'placeholder', 'aria-label', 'href', 'role', 'action', 'for', 'title', 'alt'];
+	const vw = window.innerWidth, vh = window.innerHeight;
+	const clean = s => (s || '').replace(/\s+/g, ' ').trim();
+	const esc = s => CSS.escape(s);
+
+	function selectorFor(el) {
+		const root = el.getRootNode();
+		const unique = sel => { try { return root.querySelectorAll(sel).length === 1; } catch (e) { return false; } };
+		const tag = el.tagName.toLowerCase();
+		if (el.id && unique('#' + esc(el.id))) return '#' + esc(el.id);
+		for (const attr of ['name', 'aria-label', 'placeholder', 'data-testid']) {
+			const v = el.getAttribute(attr);
+			if (v && v.length < 60) {
+				const sel = tag + '[' + attr + '="' + v.replace(/"/g, '\\"') + '"]';
+				if (unique(sel)) return sel;
+			}
+		}
+		// Walk up to an ancestor with an id, counting positions among siblings
+		const parts = [];
+		let node = el;
+		while (node && node.nodeType === Node.ELEMENT_NODE && parts.length < 6) {
+			if (node !== el && node.id && unique('#' + esc(node.id))) {
+				parts.unshift('#' + esc(node.id));
+				break;
+			}
+			let part = node.tagName.toLowerCase();
+			const parent = node.parentElement;
+			if (parent) {
+				const same = Array.from(parent.children).filter(c => c.tagName === node.tagName);
+				if (same.length > 1) part += ':nth-of-type(' + (same.indexOf(node) + 1) + ')';
+			}
+			parts.unshift(part);
+			node = parent;
+		}
+		return parts.join(' > ');
+	}
+
+	function ownText(el) {
+		let text = '';
+		for (const c of el.childNodes) {
+			if (c.nodeType === Node.TEXT_NODE) text += ' ' + c.textContent;
+		}
+		return clean(text);
+	}
+
+	function visible(el, rect) {
+		if (rect.width < 1 || rect.height < 1) return false;
+		const style = getComputedStyle(el);
+		return style.visibility !== 'hidden' && style.display !== 'none' && parseFloat(style.opacity) !== 0;
+	}
+
+	// Elements in document order, each with the "host >>> " prefix needed to
+	// select it inside open shadow roots
+	const elements = [];
+	(function collect(root, prefix) {
+		for (const el of root.querySelectorAll('*')) {
+			elements.push([el, prefix]);
+			if (el.shadowRoot) collect(el.shadowRoot, prefix + selectorFor(el) + ' >>> ');
+		}
+	})(document, '');
+
+	const nodes = [];
+	for (const [el, prefix] of elements) {
+		if (nodes.length >= limit) break;
+		if (skip.has(el.tagName.toUpperCase()) || el.closest('#__zenact_marks')) continue;
+
+		let kind = '', text = '';
+		const tag = el.tagName.toLowerCase();
+		if (el.matches(interactive)) {
+			kind = 'interactive';
+			text = clean(el.getAttribute('aria-label') || el.innerText || el.value || '');
+		} else if (/^h[1-6]$/.test(tag) || el.getAttribute('role') === 'heading') {
+			kind = 'heading';
+			text = clean(el.innerText);
+		} else if (tag === 'form') {
+			kind = 'form';
+			text = clean(el.getAttribute('aria-label') || el.getAttribute('name') || '');
+		} else if (el.closest(interactive + ', h1, h2, h3, h4, h5, h6')) {
+			continue;
+		} else {
+			text = ownText(el);
+			if (text.length < 3) continue;
+			kind = 'text';
+		}
+
+		const rect = el.getBoundingClientRect();
+		if (kind !== 'form' && !visible(el, rect)) continue;
+
+		let distance = 0;
+		if (rect.bottom < 0) distance = Math.round(rect.bottom);
+		else if (rect.top > vh) distance = Math.round(rect.top - vh);
+		else if (rect.right < 0 || rect.left > vw) distance = Math.round(Math.max(-rect.right, rect.left - vw));
+
+		const attrs = {};
+		for (const a of keep) {
+			const v = el.getAttribute(a);
+			if (v) attrs[a] = v.substring(0, 100);
+		}
+		if (kind === 'interactive' && 'value' in el && el.value && tag !== 'button') {
+			attrs.value = el.type === 'password' ? '••••' : String(el.value).substring(0, 80);
+		}
+		if (kind === 'interactive') {
+			if (el.type === 'checkbox' || el.type === 'radio') attrs.checked = String(el.checked);
+			else if (el.hasAttribute('aria-checked')) attrs.checked = el.getAttribute('aria-checked');
+			if (el.disabled || el.getAttribute('aria-disabled') === 'true') attrs.disabled = 'true';
+		}
+
+		nodes.push({
+			kind: kind,
+			tag: tag,
+			selector: prefix + selectorFor(el),
+			text: text.substring(0, 200),
+			attrs: attrs,
+			mark: parseInt(el.getAttribute('` + markAttribute + `'), 10) || 0,
+			distance: distance
+		});
+	}
+	return nodes;
+}`
+
+// maxDistilledNodes bounds how many nodes one frame contributes.
+const maxDistilledNodes = 1500
+
+// DistillDOM lists the interactive elements, headings, forms and visible text
+// of every frame with selectors the model can use. Element numbers are kept
+// only for elements marked in the current snapshot.
+func (b *Browser) DistillDOM() ([]DOMNode, error) {
+	var nodes []DOMNode
+	err := chromedp.Run(b.activeCtx(), chromedp.ActionFunc(func(ctx context.Context) error {
+		frames, err := b.frameContexts(ctx)
+		if err != nil {
+			return err
+		}
+		for i := range frames {
+			fc := &frames[i]
+			var frameNodes []DOMNode
+			if err := b.callInFrame(ctx, fc, distillScript, &frameNodes, maxDistilledNodes); err != nil {
+				if fc.selector == "" {
+					return err
+				}
+				continue
+			}
+			for _, n := range frameNodes {
+				if fc.selector != "" {
+					n.Frame = fc.selector
+					n.Selector = fc.selector + " " + piercer + " " + n.Selector
+				}
+				nodes = append(nodes, n)
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("DOM distillation failed: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range nodes {
+		nodes[i].Order = i
+		if _, ok := b.marks[nodes[i].Mark]; !ok {
+			nodes[i].Mark = 0
+		}
+	}
+	return nodes, nil
+}
+
+// EstimateTokens approximates how many model tokens a text costs.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// RenderDOM describes the nodes most relevant to query within budget tokens,
+// in document order. Nodes are ranked by kind, closeness to the viewport and
+// how many words of the query they mention.
+func RenderDOM(nodes []DOMNode, query string, budget int) string {
+	terms := queryTerms(query)
+	type ranked struct {
+		node  DOMNode
+		line  string
+		score float64
+	}
+	all := make([]ranked, len(nodes))
+	for i, n := range nodes {
+		all[i] = ranked{node: n, line: DescribeNode(n), score: nodeScore(n, terms)}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+
+	var chosen []ranked
+	used := 0
+	for _, r := range all {
+		cost := EstimateTokens(r.line) + 1
+		if used+cost > budget {
+			continue
+		}
+		used += cost
+		chosen = append(chosen, r)
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].node.Order < chosen[j].node.Order })
+
+	var sb strings.Builder
+	for _, r := range chosen {
+		sb.WriteString(r.line)
+		sb.WriteString("\n")
+	}
+	if omitted := len(nodes) - len(chosen); omitted > 0 {
+		fmt.Fprintf(&sb, "(%d less relevant nodes omitted)\n", omitted)
+	}
+	return sb.String()
+}
+
+// stopWords are skipped when matching the query against the page.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"that": true, "this": true, "then": true, "them": true, "all": true, "any": true,
+	"are": true, "was": true, "you": true, "your": true, "our": true, "its": true,
+	"on": true, "in": true, "to": true, "of": true, "a": true, "an": true, "is": true,
+	"go": true, "get": true, "find": true, "page": true, "website": true, "site": true,
+}
+
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		if len(w) < 3 || stopWords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+func nodeScore(n DOMNode, terms []string) float64 {
+	var score float64
+	switch n.Kind {
+	case NodeInteractive:
+		score = 3
+	case NodeHeading:
+		score = 2.5
+	case NodeForm:
+		score = 2
+	default:
+		score = 1
+	}
+	if n.Mark > 0 {
+		score++
+	}
+
+	d := n.Distance
+	if d < 0 {
+		d = -d
+	}
+	score += 2 / (1 + float64(d)/500)
+
+	haystack := strings.ToLower(n.Text)
+	for _, v := range n.Attrs {
+		haystack += " " + strings.ToLower(v)
+	}
+	matches := 0
+	for _, t := range terms {
+		if strings.Contains(haystack, t) {
+			matches++
+		}
+	}
+	return score + 1.5*float64(min(matches, 3))
+}
+
+// DescribeNode renders a node as one line, e.g.
+// [12] button #checkout "Place order" or h2 "Shipping address" (below).
+func DescribeNode(n DOMNode) string {
+	var sb strings.Builder
+	if n.Mark > 0 {
+		fmt.Fprintf(&sb, "[%d] ", n.Mark)
+	}
+	switch n.Kind {
+	case NodeHeading, NodeText:
+		sb.WriteString(n.Tag)
+	default:
+		fmt.Fprintf(&sb, "%s %s", n.Tag, n.Selector)
+	}
+	if n.Text != "" {
+		fmt.Fprintf(&sb, " %q", n.Text)
+	}
+	for _, k := range []string{"type", "placeholder", "value", "checked", "href", "action", "for"} {
+		if v, ok := n.Attrs[k]; ok {
+			fmt.Fprintf(&sb, " %s=%q", k, v)
+		}
+	}
+	if n.Attrs["disabled"] == "true" {
+		sb.WriteString(" disabled")
+	}
+	switch {
+	case n.Distance < 0:
+		sb.WriteString(" (above)")
+	case n.Distance > 0:
+		sb.WriteString(" (below)")
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package browser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DomainPolicy restricts which hosts pages may load from. A pattern such as
+// "example.com" matches the domain and its subdomains, "*.example.com" only
+// the subdomains and "*" every host. Deny wins over Allow; an empty Allow
+// list allows every host not denied.
+type DomainPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// ValidateDomainPatterns checks that each pattern is a bare host pattern.
+func ValidateDomainPatterns(patterns []string) error {
+	for _, p := range patterns {
+		host := strings.TrimPrefix(p, "*.")
+		if p == "*" {
+			continue
+		}
+		if host == "" || strings.ContainsAny(host, "/:*?# ") {
+			return fmt.Errorf("invalid domain pattern %q (use example.com, *.example.com or *)", p)
+		}
+	}
+	return nil
+}
+
+func (p DomainPolicy) empty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// check returns why host is not allowed, or "" if it is.
+func (p DomainPolicy) check(host string) string {
+	for _, pattern := range p.Deny {
+		if matchDomain(pattern, host) {
+			return fmt.Sprintf("%s is on the domain denylist (%s)", host, pattern)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return ""
+	}
+	for _, pattern := range p.Allow {
+		if matchDomain(pattern, host) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s is not on the domain allowlist", host)
+}
+
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	if pattern == "*" {
+		return true
+	}
+	if sub, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+sub)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// SetDomainPolicies restricts navigation and requests of every tab. A URL
+// must pass all policies, so a task's policy can narrow the server's but not
+// widen it.
+func (b *Browser) SetDomainPolicies(policies ...DomainPolicy) error {
+	b.mu.Lock()
+	b.domainPolicies = nil
+	for _, p := range policies {
+		if !p.empty() {
+			b.domainPolicies = append(b.domainPolicies, p)
+		}
+	}
+	b.mu.Unlock()
+	return b.applyInterception()
+}
+
+// CheckURL returns an error explaining why the domain policies forbid a URL.
+// Only http(s) and ws(s) URLs are restricted.
+func (b *Browser) CheckURL(rawURL string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if reason := b.domainBlockReason(rawURL); reason != "" {
+		return fmt.Errorf("blocked by policy: %s", reason)
+	}
+	return nil
+}
+
+// domainBlockReason is CheckURL's reason. b.mu must be held.
+func (b *Browser) domainBlockReason(rawURL string) string {
+	if len(b.domainPolicies) == 0 {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return ""
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, p := range b.domainPolicies {
+		if reason := p.check(host); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package browser
+
+import "testing"
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"*", "example.com", true},
+		{"example.com", "example.com", true},
+		{"example.com", "www.example.com", true},
+		{"Example.COM", "example.com", true},
+		{"example.com", "badexample.com", false},
+		{"example.com", "example.com.evil.net", false},
+		{"*.example.com", "www.example.com", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "notexample.com", false},
+	}
+	for _, tt := range tests {
+		if got := matchDomain(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("matchDomain(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDomainPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		wantErr  bool
+	}{
+		{patterns: nil},
+		{patterns: []string{"*", "example.com", "*.example.com"}},
+		{patterns: []string{""}, wantErr: true},
+		{patterns: []string{"*."}, wantErr: true},
+		{patterns: []string{"https://example.com"}, wantErr: true},
+		{patterns: []string{"example.com/path"}, wantErr: true},
+		{patterns: []string{"example.com:8080"}, wantErr: true},
+		{patterns: []string{"ex*ample.com"}, wantErr: true},
+		{patterns: []string{"example.com", "*.*"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateDomainPatterns(tt.patterns); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDomainPatterns(%q) = %v, want error %v", tt.patterns, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDomainBlockReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []DomainPolicy
+		url      string
+		blocked  bool
+	}{
+		{name: "no policy", url: "https://anything.net"},
+		{name: "allowed", policies: []DomainPolicy{{Allow: []string{"example.com"}}}, url: "https://www.example.com/a"},
+		{name: "not allowed", policies: []DomainPolicy{{Allow: []string{"example.com"}}}, url: "https://other.net", blocked: true},
+		{name: "denied", policies: []DomainPolicy{{Deny: []string{"ads.example.com"}}}, url: "http://ads.example.com", blocked: true},
+		{name: "deny wins", policies: []DomainPolicy{{Allow: []string{"*"}, Deny: []string{"evil.net"}}}, url: "https://evil.net", blocked: true},
+		{name: "trailing dot and case", policies: []DomainPolicy{{Deny: []string{"evil.net"}}}, url: "https://EVIL.net./x", blocked: true},
+		{name: "websocket", policies: []DomainPolicy{{Allow: []string{"example.com"}}}, url: "wss://other.net/socket", blocked: true},
+		{name: "other scheme", policies: []DomainPolicy{{Allow: []string{"example.com"}}}, url: "about:blank"},
+		{
+			name:     "every policy must pass",
+			policies: []DomainPolicy{{Allow: []string{"example.com"}}, {Allow: []string{"docs.example.com"}}},
+			url:      "https://www.example.com",
+			blocked:  true,
+		},
+	}
+	for _, tt := range tests {
+		b := &Browser{domainPolicies: tt.policies}
+		reason := b.domainBlockReason(tt.url)
+		if (reason != "") != tt.blocked {
+			t.Errorf("%s: domainBlockReason(%q) = %q, want blocked %v", tt.name, tt.url, reason, tt.blocked)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// Download is a file the page downloaded into the download directory.
+type Download struct {
+	GUID     string
+	URL      string
+	Name     string
+	Path     string
+	Size     int64
+	MIMEType string
+}
+
+// EnableDownloads saves downloads into dir instead of discarding them.
+func (b *Browser) EnableDownloads(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	b.mu.Lock()
+	b.downloadDir = dir
+	b.mu.Unlock()
+
+	// allowAndName stores each download under its GUID; it is renamed to the
+	// suggested file name once complete
+	return chromedp.Run(b.activeCtx(), cdpbrowser.SetDownloadBehavior(cdpbrowser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithDownloadPath(dir).
+		WithEventsEnabled(true))
+}
+
+func (b *Browser) onDownloadWillBegin(ev *cdpbrowser.EventDownloadWillBegin) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pendingDownloads[ev.GUID] = &Download{
+		GUID: ev.GUID,
+		URL:  ev.URL,
+		Name: ev.SuggestedFilename,
+	}
+}
+
+func (b *Browser) onDownloadProgress(ev *cdpbrowser.EventDownloadProgress) {
+	if ev.State == cdpbrowser.DownloadProgressStateInProgress {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d, ok := b.pendingDownloads[ev.GUID]
+	if !ok {
+		return
+	}
+	delete(b.pendingDownloads, ev.GUID)
+
+	if ev.State != cdpbrowser.DownloadProgressStateCompleted {
+		os.Remove(filepath.Join(b.downloadDir, ev.GUID))
+		return
+	}
+
+	name := filepath.Base(strings.ReplaceAll(d.Name, `\`, "/"))
+	if name == "" || name == "." || name == "/" {
+		name = "download"
+	}
+	name = FreeFileName(b.downloadDir, name)
+	path := filepath.Join(b.downloadDir, name)
+	if err := os.Rename(filepath.Join(b.downloadDir, ev.GUID), path); err != nil {
+		log.Printf("WARNING: could not store download %s: %v", d.URL, err)
+		return
+	}
+
+	d.Name = name
+	d.Path = path
+	if info, err := os.Stat(path); err == nil {
+		d.Size = info.Size()
+	}
+	d.MIMEType = detectMIMEType(path)
+	b.completedDownloads = append(b.completedDownloads, *d)
+}
+
+// CompletedDownloads returns the downloads finished since the previous call.
+func (b *Browser) CompletedDownloads() []Download {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	done := b.completedDownloads
+	b.completedDownloads = nil
+	return done
+}
+
+// detectMIMEType guesses a file's type from its extension, falling back to
+// sniffing its first bytes.
+func detectMIMEType(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
+
+// FreeName returns name, or name with " (n)" before its extension for the
+// smallest n that taken does not report, the way file managers number copies.
+func FreeName(name string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// FreeFileName numbers name with FreeName until no file in dir has it.
+func FreeFileName(dir, name string) string {
+	return FreeName(name, func(n string) bool {
+		_, err := os.Stat(filepath.Join(dir, n))
+		return !os.IsNotExist(err)
+	})
+}
@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{name: "report.pdf", want: "report.pdf"},
+		{name: "report.pdf", taken: []string{"other.pdf"}, want: "report.pdf"},
+		{name: "report.pdf", taken: []string{"report.pdf"}, want: "report (1).pdf"},
+		{name: "report.pdf", taken: []string{"report.pdf", "report (1).pdf"}, want: "report (2).pdf"},
+		{name: "report.pdf", taken: []string{"report.pdf", "report (2).pdf"}, want: "report (1).pdf"},
+		{name: "archive.tar.gz", taken: []string{"archive.tar.gz"}, want: "archive.tar (1).gz"},
+		{name: "README", taken: []string{"README"}, want: "README (1)"},
+	}
+	for _, tt := range tests {
+		taken := make(map[string]bool)
+		for _, n := range tt.taken {
+			taken[n] = true
+		}
+		if got := FreeName(tt.name, func(n string) bool { return taken[n] }); got != tt.want {
+			t.Errorf("FreeName(%q) with %q taken = %q, want %q", tt.name, tt.taken, got, tt.want)
+		}
+	}
+}
+
+func TestFreeFileName(t *testing.T) {
+	dir := t.TempDir()
+	for _, n := range []string{"data.csv", "data (1).csv"} {
+		if err := os.WriteFile(filepath.Join(dir, n), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name, want string
+	}{
+		{"data.csv", "data (2).csv"},
+		{"new.csv", "new.csv"},
+	}
+	for _, tt := range tests {
+		if got := FreeFileName(dir, tt.name); got != tt.want {
+			t.Errorf("FreeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone names are checked even without system zoneinfo
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// desktopUserAgent is presented when no device preset is selected.
+const desktopUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// Device is a screen and user agent to emulate.
+type Device struct {
+	Width     int64 // CSS pixels
+	Height    int64
+	Scale     float64 // device pixel ratio
+	Mobile    bool
+	Touch     bool
+	UserAgent string
+	Platform  string // navigator.platform
+}
+
+// Devices are the presets a task can select by name.
+var Devices = map[string]Device{
+	"iphone-15": {
+		Width: 393, Height: 852, Scale: 3, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Platform:  "iPhone",
+	},
+	"pixel-7": {
+		Width: 412, Height: 915, Scale: 2.625, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+		Platform:  "Linux armv8l",
+	},
+	"ipad": {
+		Width: 820, Height: 1180, Scale: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		Platform:  "iPad",
+	},
+	"galaxy-tab-s9": {
+		Width: 800, Height: 1280, Scale: 2, Mobile: true, Touch: true,
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		Platform:  "Linux armv8l",
+	},
+}
+
+// Emulation overrides how the browser presents itself to pages.
+type Emulation struct {
+	Device      string // name from Devices; empty or "desktop" keeps the window size
+	Locale      string // e.g. de-DE; sets navigator.language, Intl and Accept-Language
+	Timezone    string // IANA name, e.g. Europe/Berlin
+	Geolocation *Geolocation
+}
+
+// Geolocation is a position reported by navigator.geolocation.
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64 // meters
+}
+
+// DeviceNames lists the device presets.
+func DeviceNames() []string {
+	names := make([]string, 0, len(Devices))
+	for name := range Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localeTag matches BCP 47 language tags of the form language[-script]
+// [-region][-variant...], e.g. en, de-DE, zh-Hant-TW or sl-rozaj.
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z]{4})?(-[A-Za-z]{2}|-[0-9]{3})?(-[A-Za-z0-9]{5,8}|-[0-9][A-Za-z0-9]{3})*$`)
+
+// Validate checks the device name, locale, timezone and coordinates.
+func (e Emulation) Validate() error {
+	if _, ok := Devices[e.Device]; !ok && e.Device != "" && e.Device != "desktop" {
+		return fmt.Errorf("unknown device %q (use desktop, %s)", e.Device, strings.Join(DeviceNames(), ", "))
+	}
+	if e.Locale != "" && !localeTag.MatchString(e.Locale) {
+		return fmt.Errorf("invalid locale %q (use a language tag such as en-US)", e.Locale)
+	}
+	if e.Timezone != "" {
+		if _, err := time.LoadLocation(e.Timezone); err != nil || e.Timezone == "Local" {
+			return fmt.Errorf("unknown timezone %q (use an IANA name such as Europe/Berlin)", e.Timezone)
+		}
+	}
+	if g := e.Geolocation; g != nil {
+		if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 {
+			return fmt.Errorf("geolocation %v,%v is out of range", g.Latitude, g.Longitude)
+		}
+		if g.Accuracy < 0 {
+			return fmt.Errorf("geolocation accuracy %v is negative", g.Accuracy)
+		}
+	}
+	return nil
+}
+
+// SetEmulation applies device, locale, timezone and geolocation overrides to
+// every tab, including tabs opened later.
+func (b *Browser) SetEmulation(e Emulation) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.Geolocation != nil {
+		if err := chromedp.Run(b.rootCtx, cdpbrowser.GrantPermissions([]cdpbrowser.PermissionType{cdpbrowser.PermissionTypeGeolocation})); err != nil {
+			return fmt.Errorf("granting geolocation permission failed: %w", err)
+		}
+	}
+
+	b.mu.Lock()
+	b.emulation = e
+	var ctxs []context.Context
+	for _, t := range b.tabs {
+		if t.ctx != nil && !t.closed {
+			ctxs = append(ctxs, t.ctx)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, ctx := range ctxs {
+		if err := b.applyEmulation(ctx); err != nil {
+			return err
+		}
+	}
+	// Coordinates map through the new viewport from the next screenshot on
+	b.mu.Lock()
+	b.frame = frameGeometry{}
+	b.mu.Unlock()
+	return nil
+}
+
+// applyEmulation applies the emulation settings to one tab.
+func (b *Browser) applyEmulation(ctx context.Context) error {
+	b.mu.Lock()
+	e := b.emulation
+	b.mu.Unlock()
+
+	var actions chromedp.Tasks
+	device, ok := Devices[e.Device]
+	if ok {
+		actions = append(actions,
+			emulation.SetDeviceMetricsOverride(device.Width, device.Height, device.Scale, device.Mobile).
+				WithScreenWidth(device.Width).
+				WithScreenHeight(device.Height),
+			emulation.SetTouchEmulationEnabled(device.Touch).WithMaxTouchPoints(5),
+			// Clicks arrive as taps on sites that only listen for touch events
+			emulation.SetEmitTouchEventsForMouse(device.Touch).WithConfiguration(emulation.SetEmitTouchEventsForMouseConfigurationMobile),
+		)
+	} else {
+		device = Device{UserAgent: desktopUserAgent, Platform: "MacIntel"}
+	}
+	if ok || e.Locale != "" {
+		ua := emulation.SetUserAgentOverride(device.UserAgent).WithPlatform(device.Platform)
+		if e.Locale != "" {
+			ua = ua.WithAcceptLanguage(acceptLanguage(e.Locale))
+		}
+		actions = append(actions, ua)
+	}
+	if e.Locale != "" {
+		actions = append(actions, emulation.SetLocaleOverride().WithLocale(e.Locale))
+	}
+	if e.Timezone != "" {
+		actions = append(actions, emulation.SetTimezoneOverride(e.Timezone))
+	}
+	if g := e.Geolocation; g != nil {
+		accuracy := g.Accuracy
+		if accuracy <= 0 {
+			accuracy = 50
+		}
+		actions = append(actions, emulation.SetGeolocationOverride().
+			WithLatitude(g.Latitude).
+			WithLongitude(g.Longitude).
+			WithAccuracy(accuracy))
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+	if err := chromedp.Run(ctx, actions); err != nil {
+		return fmt.Errorf("applying emulation failed: %w", err)
+	}
+	return nil
+}
+
+// acceptLanguage builds an Accept-Language header preferring locale, e.g.
+// "de-DE,de;q=0.9".
+func acceptLanguage(locale string) string {
+	lang, _, found := strings.Cut(locale, "-")
+	if !found {
+		return locale
+	}
+	return fmt.Sprintf("%s,%s;q=0.9", locale, lang)
+}
@@ -0,0 +1,79 @@
+package browser
+
+import (
+	"context"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// listen routes the CDP events of a page target to the Browser's handlers.
+// Handlers run on chromedp's event goroutine, so they must not block or issue
+// CDP commands synchronously.
+func (b *Browser) listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *cdpbrowser.EventDownloadWillBegin:
+			b.onDownloadWillBegin(e)
+		case *cdpbrowser.EventDownloadProgress:
+			b.onDownloadProgress(e)
+		case *page.EventJavascriptDialogOpening:
+			b.onDialogOpening(ctx, e)
+		case *page.EventJavascriptDialogClosed:
+			b.onDialogClosed(ctx)
+		case *network.EventRequestWillBeSent:
+			b.onRequestStarted(ctx, e.RequestID)
+			b.onNetworkRequest(e)
+		case *network.EventResponseReceived:
+			b.onNetworkResponse(e)
+		case *network.EventLoadingFinished:
+			b.onRequestDone(ctx, e.RequestID)
+			b.onNetworkFinished(ctx, e)
+			b.forgetAuth(e.RequestID)
+		case *network.EventLoadingFailed:
+			b.onRequestDone(ctx, e.RequestID)
+			b.onNetworkFailed(e)
+			b.forgetAuth(e.RequestID)
+		case *page.EventFrameStartedLoading:
+			b.onFrameLoading(ctx, e.FrameID, true)
+		case *page.EventFrameStoppedLoading:
+			b.onFrameLoading(ctx, e.FrameID, false)
+		case *runtime.EventExecutionContextCreated:
+			b.onContextCreated(e.Context)
+		case *runtime.EventExecutionContextDestroyed:
+			b.onContextDestroyed(e.ExecutionContextID)
+		case *fetch.EventRequestPaused:
+			b.onRequestPaused(ctx, e)
+		case *fetch.EventAuthRequired:
+			b.onAuthRequired(ctx, e)
+		case *runtime.EventConsoleAPICalled:
+			b.onConsoleAPICalled(e)
+		case *runtime.EventExceptionThrown:
+			b.onExceptionThrown(e)
+		case *page.EventScreencastFrame:
+			b.onScreencastFrame(ctx, e)
+		}
+	})
+}
+
+// listenBrowser routes browser-wide events, which chromedp delivers once for
+// all tabs.
+func (b *Browser) listenBrowser(ctx context.Context) {
+	chromedp.ListenBrowser(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *target.EventTargetCreated:
+			b.onTargetCreated(e.TargetInfo)
+		case *target.EventTargetInfoChanged:
+			b.onTargetInfoChanged(e.TargetInfo)
+		case *target.EventTargetDestroyed:
+			b.onTargetDestroyed(e.TargetID)
+		case *target.EventAttachedToTarget:
+			b.onAutoAttached(e)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// SetUploadDir sets the directory uploads are restricted to. Without one,
+// upload actions are rejected.
+func (b *Browser) SetUploadDir(dir string) {
+	b.uploadDir = dir
+}
+
+// sandboxPath resolves a bare file name inside dir, rejecting anything that
+// could point outside it.
+func sandboxPath(dir, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", fmt.Errorf("file %q is not available for upload", name)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("file %q is not a regular file", name)
+	}
+	return path, nil
+}
+
+// findFileInputJS returns the file input the target refers to: the element
+// itself, the control of a <label>, or a file input nested inside it.
+const findFileInputJS = `function() {
+	const isFile = el => el && el.tagName === 'INPUT' && el.type === 'file';
+	if (isFile(this)) return this;
+	if (this.tagName === 'LABEL' && isFile(this.control)) return this.control;
+	return this.querySelector('input[type="file"]');
+}`
+
+// UploadFiles sets files from the upload directory on a file input.
+func (b *Browser) UploadFiles(t Target, names []string) error {
+	if b.uploadDir == "" {
+		return fmt.Errorf("no files were attached to this task")
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no file names given")
+	}
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path, err := sandboxPath(b.uploadDir, name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	return b.runOnTarget(t, func(ctx context.Context, id cdp.BackendNodeID) error {
+		obj, err := dom.ResolveNode().WithBackendNodeID(id).WithObjectGroup(formObjectGroup).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		defer runtime.ReleaseObjectGroup(formObjectGroup).Do(ctx)
+
+		fileInput, exc, err := runtime.CallFunctionOn(findFileInputJS).WithObjectID(obj.ObjectID).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		if exc != nil {
+			return exc
+		}
+		if fileInput.Subtype != runtime.SubtypeNode || fileInput.ObjectID == "" {
+			return fmt.Errorf("%s is not a file input; target the <input type=\"file\"> (selector input[type=file]) instead", t)
+		}
+
+		if err := dom.SetFileInputFiles(paths).WithObjectID(fileInput.ObjectID).Do(ctx); err != nil {
+			return fmt.Errorf("setting files on %s failed: %w", t, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+const formObjectGroup = "zenact-forms"
+
+// callOn runs a JS function with the node as `this` and returns its string result.
+func callOn(ctx context.Context, id cdp.BackendNodeID, fn string, args ...string) (string, error) {
+	obj, err := dom.ResolveNode().WithBackendNodeID(id).WithObjectGroup(formObjectGroup).Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer runtime.ReleaseObjectGroup(formObjectGroup).Do(ctx)
+
+	callArgs := make([]*runtime.CallArgument, len(args))
+	for i, a := range args {
+		raw, _ := json.Marshal(a)
+		callArgs[i] = &runtime.CallArgument{Value: raw}
+	}
+	res, exc, err := runtime.CallFunctionOn(fn).
+		WithObjectID(obj.ObjectID).
+		WithArguments(callArgs).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	if exc != nil {
+		return "", exc
+	}
+	var out string
+	if len(res.Value) > 0 {
+		if err := json.Unmarshal(res.Value, &out); err != nil {
+			return "", fmt.Errorf("unexpected result %s", res.Value)
+		}
+	}
+	return out, nil
+}
+
+func (b *Browser) runOnTarget(t Target, fn func(ctx context.Context, id cdp.BackendNodeID) error) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := b.resolve(ctx, t)
+		if err != nil {
+			return err
+		}
+		// Best effort: hidden controls such as styled file inputs have no box
+		// to scroll to but can still be operated on
+		dom.ScrollIntoViewIfNeeded().WithBackendNodeID(id).Do(ctx)
+		return fn(ctx, id)
+	}))
+}
+
+const selectOptionJS = `function(option) {
+	if (this.tagName !== 'SELECT') return 'NOT_SELECT';
+	const wanted = option.trim().toLowerCase();
+	const opts = Array.from(this.options);
+	const match = opts.find(o => o.value === option) ||
+		opts.find(o => o.label.trim() === option.trim()) ||
+		opts.find(o => o.label.trim().toLowerCase() === wanted) ||
+		opts.find(o => o.label.toLowerCase().includes(wanted));
+	if (!match) return 'NO_OPTION:' + opts.map(o => o.label.trim()).join(' | ');
+	if (match.disabled) return 'DISABLED:' + match.label.trim();
+	this.focus();
+	this.value = match.value;
+	match.selected = true;
+	this.dispatchEvent(new Event('input', { bubbles: true }));
+	this.dispatchEvent(new Event('change', { bubbles: true }));
+	return 'OK';
+}`
+
+// SelectOption picks an option of a native <select> by value or visible label.
+func (b *Browser) SelectOption(t Target, option string) error {
+	return b.runOnTarget(t, func(ctx context.Context, id cdp.BackendNodeID) error {
+		res, err := callOn(ctx, id, selectOptionJS, option)
+		if err != nil {
+			return fmt.Errorf("select on %s failed: %w", t, err)
+		}
+		switch {
+		case res == "OK":
+			return nil
+		case res == "NOT_SELECT":
+			return fmt.Errorf("%s is not a native <select>; click it and then click the option instead", t)
+		case strings.HasPrefix(res, "NO_OPTION:"):
+			return fmt.Errorf("%s has no option %q; available: %s", t, option, strings.TrimPrefix(res, "NO_OPTION:"))
+		case strings.HasPrefix(res, "DISABLED:"):
+			return fmt.Errorf("option %q of %s is disabled", strings.TrimPrefix(res, "DISABLED:"), t)
+		default:
+			return fmt.Errorf("select on %s failed: %s", t, res)
+		}
+	})
+}
+
+const checkedStateJS = `function() {
+	if (this.tagName === 'INPUT' && (this.type === 'checkbox' || this.type === 'radio')) {
+		return this.type + ':' + (this.checked ? 'true' : 'false');
+	}
+	const aria = this.getAttribute('aria-checked');
+	if (aria !== null) return (this.getAttribute('role') || 'checkbox') + ':' + (aria === 'true' ? 'true' : 'false');
+	return 'NA';
+}`
+
+// SetChecked checks or unchecks a checkbox, radio button or ARIA switch by
+// clicking it, so the page's own handlers run, and verifies the new state.
+func (b *Browser) SetChecked(t Target, checked bool) error {
+	want := "false"
+	if checked {
+		want = "true"
+	}
+
+	return b.runOnTarget(t, func(ctx context.Context, id cdp.BackendNodeID) error {
+		state, err := callOn(ctx, id, checkedStateJS)
+		if err != nil {
+			return fmt.Errorf("reading state of %s failed: %w", t, err)
+		}
+		kind, current, ok := strings.Cut(state, ":")
+		if !ok {
+			return fmt.Errorf("%s is not a checkbox, radio button or switch", t)
+		}
+		if current == want {
+			return nil
+		}
+		if kind == "radio" && !checked {
+			return fmt.Errorf("%s is a radio button and cannot be unchecked; check another option in the group instead", t)
+		}
+
+		x, y, err := quadCenter(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		if err := mouseClick(ctx, x, y, 1); err != nil {
+			return err
+		}
+
+		state, err = callOn(ctx, id, checkedStateJS)
+		if err != nil {
+			return fmt.Errorf("reading state of %s failed: %w", t, err)
+		}
+		if _, current, _ = strings.Cut(state, ":"); current != want {
+			return fmt.Errorf("clicking %s did not change its checked state; it may be disabled or covered by its label", t)
+		}
+		return nil
+	})
+}
+
+const setValueJS = `function(value) {
+	const proto = this instanceof HTMLTextAreaElement ? HTMLTextAreaElement.prototype :
+		this instanceof HTMLSelectElement ? HTMLSelectElement.prototype :
+		this instanceof HTMLInputElement ? HTMLInputElement.prototype : null;
+	if (!proto) return 'NOT_INPUT';
+	this.focus();
+	// Use the native setter so framework-controlled inputs notice the change
+	Object.getOwnPropertyDescriptor(proto, 'value').set.call(this, value);
+	this.dispatchEvent(new Event('input', { bubbles: true }));
+	this.dispatchEvent(new Event('change', { bubbles: true }));
+	return 'VALUE:' + this.value;
+}`
+
+// SetValue assigns a value directly to an input, which is how date, time,
+// color and range inputs are filled since they ignore typed keys.
+func (b *Browser) SetValue(t Target, value string) error {
+	return b.runOnTarget(t, func(ctx context.Context, id cdp.BackendNodeID) error {
+		res, err := callOn(ctx, id, setValueJS, value)
+		if err != nil {
+			return fmt.Errorf("set value on %s failed: %w", t, err)
+		}
+		if res == "NOT_INPUT" {
+			return fmt.Errorf("%s is not an input, textarea or select", t)
+		}
+		got := strings.TrimPrefix(res, "VALUE:")
+		switch {
+		case sameValue(value, got):
+			return nil
+		case got == "":
+			return fmt.Errorf("%s rejected value %q; date inputs expect YYYY-MM-DD, time HH:MM, datetime-local YYYY-MM-DDTHH:MM", t, value)
+		default:
+			return fmt.Errorf("%s adjusted value %q to %q to fit its min, max or step", t, value, got)
+		}
+	})
+}
+
+// sameValue reports whether an input's value is what was set once the
+// browser normalized it, such as #FF0000 stored as #ff0000 or 5.0 as 5.
+func sameValue(set, got string) bool {
+	if strings.EqualFold(set, got) {
+		return true
+	}
+	a, errA := strconv.ParseFloat(strings.TrimSpace(set), 64)
+	b, errB := strconv.ParseFloat(got, 64)
+	return errA == nil && errB == nil && a == b
+}
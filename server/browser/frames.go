@@ -0,0 +1,298 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// piercer separates the parts of a selector that crosses into an iframe or a
+// shadow root, e.g. `iframe#payment >>> input[name="card"]` or
+// `checkout-form >>> button.submit`.
+const piercer = ">>>"
+
+const (
+	frameObjectGroup = "zenact-frames"
+	frameWorldName   = "zenact"
+)
+
+var plainIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// frameContext is a frame of the active tab together with the JS context
+// snapshot scripts run in. Scripts run through CDP, so cross-origin frames
+// can be read as well once site isolation is disabled; frames isolated in
+// another process are skipped.
+type frameContext struct {
+	id       cdp.FrameID
+	url      string
+	selector string // piercing selector of the owner iframe, "" for the main frame
+	offsetX  float64
+	offsetY  float64
+	world    runtime.ExecutionContextID
+}
+
+// frameContexts lists the main frame and every rendered child frame of the
+// active tab, main frame first.
+func (b *Browser) frameContexts(ctx context.Context) ([]frameContext, error) {
+	tree, err := page.GetFrameTree().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading frame tree failed: %w", err)
+	}
+
+	var frames []frameContext
+	var walk func(t *page.FrameTree, parent *frameContext)
+	walk = func(t *page.FrameTree, parent *frameContext) {
+		fc := frameContext{id: t.Frame.ID, url: t.Frame.URL}
+		if parent != nil {
+			owner, _, err := dom.GetFrameOwner(t.Frame.ID).Do(ctx)
+			if err != nil {
+				return
+			}
+			// Frames without a box (display:none, detached) are skipped
+			box, err := dom.GetBoxModel().WithBackendNodeID(owner).Do(ctx)
+			if err != nil || len(box.Content) < 2 {
+				return
+			}
+			fc.offsetX, fc.offsetY = box.Content[0], box.Content[1]
+			fc.selector = ownerSelector(ctx, owner)
+			if parent.selector != "" {
+				fc.selector = parent.selector + " " + piercer + " " + fc.selector
+			}
+		}
+		world, err := b.frameWorld(ctx, t.Frame.ID, false)
+		if err != nil {
+			return
+		}
+		fc.world = world
+		frames = append(frames, fc)
+		for _, child := range t.ChildFrames {
+			walk(child, &fc)
+		}
+	}
+	walk(tree, nil)
+
+	if len(frames) == 0 || frames[0].selector != "" {
+		return nil, fmt.Errorf("main frame is not available")
+	}
+	return frames, nil
+}
+
+// frameWorld returns the execution context scripts run in for a frame: the
+// page's own context when it has been reported, otherwise an isolated world
+// created on first use. fresh discards a context that turned out to be gone.
+func (b *Browser) frameWorld(ctx context.Context, id cdp.FrameID, fresh bool) (runtime.ExecutionContextID, error) {
+	b.mu.Lock()
+	if fresh {
+		delete(b.contexts, id)
+		delete(b.worlds, id)
+	} else {
+		world, ok := b.contexts[id]
+		if !ok {
+			world, ok = b.worlds[id]
+		}
+		if ok {
+			b.mu.Unlock()
+			return world, nil
+		}
+	}
+	b.mu.Unlock()
+
+	world, err := page.CreateIsolatedWorld(id).WithWorldName(frameWorldName).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.worlds == nil {
+		b.worlds = make(map[cdp.FrameID]runtime.ExecutionContextID)
+	}
+	b.worlds[id] = world
+	return world, nil
+}
+
+// onContextCreated remembers the default execution context of each frame.
+func (b *Browser) onContextCreated(desc *runtime.ExecutionContextDescription) {
+	var aux struct {
+		IsDefault bool        `json:"isDefault"`
+		FrameID   cdp.FrameID `json:"frameId"`
+	}
+	if err := json.Unmarshal(desc.AuxData, &aux); err != nil || !aux.IsDefault || aux.FrameID == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.contexts == nil {
+		b.contexts = make(map[cdp.FrameID]runtime.ExecutionContextID)
+	}
+	b.contexts[aux.FrameID] = desc.ID
+}
+
+func (b *Browser) onContextDestroyed(id runtime.ExecutionContextID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for frame, world := range b.contexts {
+		if world == id {
+			delete(b.contexts, frame)
+		}
+	}
+}
+
+// evalInFrame evaluates a script expression in a frame and decodes its result.
+func (b *Browser) evalInFrame(ctx context.Context, fc *frameContext, expr string, out interface{}) error {
+	return b.retryInFrame(ctx, fc, func() (*runtime.RemoteObject, *runtime.ExceptionDetails, error) {
+		return runtime.Evaluate(expr).
+			WithContextID(fc.world).
+			WithReturnByValue(true).
+			WithAwaitPromise(true).
+			Do(ctx)
+	}, out)
+}
+
+// callInFrame calls a JS function with JSON-encodable arguments in a frame and
+// decodes its result.
+func (b *Browser) callInFrame(ctx context.Context, fc *frameContext, fn string, out interface{}, args ...interface{}) error {
+	callArgs := make([]*runtime.CallArgument, len(args))
+	for i, a := range args {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		callArgs[i] = &runtime.CallArgument{Value: raw}
+	}
+	return b.retryInFrame(ctx, fc, func() (*runtime.RemoteObject, *runtime.ExceptionDetails, error) {
+		return runtime.CallFunctionOn(fn).
+			WithExecutionContextID(fc.world).
+			WithArguments(callArgs).
+			WithReturnByValue(true).
+			WithAwaitPromise(true).
+			Do(ctx)
+	}, out)
+}
+
+// retryInFrame runs a script call, looking up the frame's context again once
+// if it was destroyed by a navigation since it was cached.
+func (b *Browser) retryInFrame(ctx context.Context, fc *frameContext, call func() (*runtime.RemoteObject, *runtime.ExceptionDetails, error), out interface{}) error {
+	res, exc, err := call()
+	if err != nil {
+		world, werr := b.frameWorld(ctx, fc.id, true)
+		if werr != nil {
+			return err
+		}
+		fc.world = world
+		res, exc, err = call()
+	}
+	if err != nil {
+		return err
+	}
+	if exc != nil {
+		return exc
+	}
+	if out == nil || len(res.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Value, out)
+}
+
+// ownerSelector builds a selector for the iframe element owning a frame.
+func ownerSelector(ctx context.Context, owner cdp.BackendNodeID) string {
+	node, err := dom.DescribeNode().WithBackendNodeID(owner).Do(ctx)
+	if err != nil {
+		return "iframe"
+	}
+	tag := strings.ToLower(node.LocalName)
+	if id := node.AttributeValue("id"); id != "" {
+		if plainIdent.MatchString(id) {
+			return tag + "#" + id
+		}
+		return fmt.Sprintf("%s[id=%q]", tag, id)
+	}
+	for _, attr := range []string{"name", "title", "src"} {
+		if v := node.AttributeValue(attr); v != "" {
+			return fmt.Sprintf("%s[%s=%q]", tag, attr, v)
+		}
+	}
+	return tag
+}
+
+// queryPiercing resolves a selector whose parts are separated by >>>. Every
+// part but the last must match an iframe, whose document the next part is
+// matched in, or a shadow host, whose shadow root is searched next. The lookup
+// goes through CDP rather than page script, so cross-origin frames and closed
+// shadow roots are reachable too.
+func queryPiercing(ctx context.Context, selector string) (cdp.BackendNodeID, error) {
+	defer runtime.ReleaseObjectGroup(frameObjectGroup).Do(ctx)
+
+	scope, exc, err := runtime.Evaluate("document").WithObjectGroup(frameObjectGroup).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("query %s failed: %w", selector, err)
+	}
+	if exc != nil {
+		return 0, fmt.Errorf("query %s failed: %w", selector, exc)
+	}
+
+	parts := strings.Split(selector, piercer)
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return 0, fmt.Errorf("selector %q has an empty part", selector)
+		}
+		node, err := querySelectorIn(ctx, scope.ObjectID, part)
+		if err != nil {
+			return 0, err
+		}
+		if node == nil {
+			return 0, fmt.Errorf("element not found: %s (in %s)", part, selector)
+		}
+		if i == len(parts)-1 {
+			return node.BackendNodeID, nil
+		}
+
+		var next *cdp.Node
+		if node.ContentDocument != nil {
+			next = node.ContentDocument
+		} else {
+			for _, root := range node.ShadowRoots {
+				if root.ShadowRootType != cdp.ShadowRootTypeUserAgent {
+					next = root
+					break
+				}
+			}
+		}
+		if next == nil {
+			return 0, fmt.Errorf("%s is neither an iframe nor a shadow host", part)
+		}
+		if scope, err = dom.ResolveNode().WithBackendNodeID(next.BackendNodeID).WithObjectGroup(frameObjectGroup).Do(ctx); err != nil {
+			return 0, fmt.Errorf("entering %s failed: %w", part, err)
+		}
+	}
+	return 0, fmt.Errorf("selector %q is empty", selector)
+}
+
+// querySelectorIn matches a selector inside a document or shadow root object
+// and describes the first match, including its content document and shadow
+// roots. It returns nil if nothing matches.
+func querySelectorIn(ctx context.Context, scope runtime.RemoteObjectID, selector string) (*cdp.Node, error) {
+	raw, _ := json.Marshal(selector)
+	res, exc, err := runtime.CallFunctionOn(`function(s) { return this.querySelector(s); }`).
+		WithObjectID(scope).
+		WithArguments([]*runtime.CallArgument{{Value: raw}}).
+		WithObjectGroup(frameObjectGroup).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query %s failed: %w", selector, err)
+	}
+	if exc != nil {
+		return nil, fmt.Errorf("query %s failed: %w", selector, exc)
+	}
+	if res.ObjectID == "" {
+		return nil, nil
+	}
+	return dom.DescribeNode().WithObjectID(res.ObjectID).WithDepth(1).WithPierce(true).Do(ctx)
+}
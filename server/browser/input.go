@@ -0,0 +1,191 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+// dragSteps is the number of intermediate mousemove events sent during a drag.
+const dragSteps = 10
+
+// mouseClick dispatches a trusted left click at viewport (CSS pixel) coordinates.
+func mouseClick(ctx context.Context, x, y float64, clickCount int64) error {
+	if err := mouseMove(ctx, x, y); err != nil {
+		return err
+	}
+	for i := int64(1); i <= clickCount; i++ {
+		if err := input.DispatchMouseEvent(input.MousePressed, x, y).
+			WithButton(input.Left).
+			WithButtons(1).
+			WithClickCount(i).
+			Do(ctx); err != nil {
+			return fmt.Errorf("mouse press failed: %w", err)
+		}
+		if err := input.DispatchMouseEvent(input.MouseReleased, x, y).
+			WithButton(input.Left).
+			WithClickCount(i).
+			Do(ctx); err != nil {
+			return fmt.Errorf("mouse release failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// mouseMove moves the pointer without pressing any button.
+func mouseMove(ctx context.Context, x, y float64) error {
+	if err := input.DispatchMouseEvent(input.MouseMoved, x, y).Do(ctx); err != nil {
+		return fmt.Errorf("mouse move failed: %w", err)
+	}
+	return nil
+}
+
+// mouseDown moves to a point and presses the left button there.
+func mouseDown(ctx context.Context, x, y float64) error {
+	if err := mouseMove(ctx, x, y); err != nil {
+		return err
+	}
+	if err := input.DispatchMouseEvent(input.MousePressed, x, y).
+		WithButton(input.Left).
+		WithButtons(1).
+		WithClickCount(1).
+		Do(ctx); err != nil {
+		return fmt.Errorf("mouse press failed: %w", err)
+	}
+	return nil
+}
+
+// mouseUp releases the left button at a point.
+func mouseUp(ctx context.Context, x, y float64) error {
+	if err := input.DispatchMouseEvent(input.MouseReleased, x, y).
+		WithButton(input.Left).
+		WithClickCount(1).
+		Do(ctx); err != nil {
+		return fmt.Errorf("mouse release failed: %w", err)
+	}
+	return nil
+}
+
+// mouseHold presses the left button at a point, keeps it down for the given
+// duration and releases it at the same point.
+func mouseHold(ctx context.Context, x, y float64, duration time.Duration) error {
+	if err := mouseDown(ctx, x, y); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		// Still try to release so the page is not left with a stuck button
+		mouseUp(context.WithoutCancel(ctx), x, y)
+		return ctx.Err()
+	case <-time.After(duration):
+	}
+	return mouseUp(ctx, x, y)
+}
+
+// mouseDrag presses the left button at the start point, moves to the end point
+// in small steps so pages tracking mousemove see a continuous gesture, and
+// releases it there. Native HTML5 drag and drop does not start from synthetic
+// mouse moves alone, so drags are intercepted and replayed as drag events
+// targeted at the end point.
+func mouseDrag(ctx context.Context, fromX, fromY, toX, toY float64) (err error) {
+	if err := input.SetInterceptDrags(true).Do(ctx); err != nil {
+		return fmt.Errorf("enabling drag interception failed: %w", err)
+	}
+	defer input.SetInterceptDrags(false).Do(ctx)
+
+	intercepted := make(chan *input.DragData, 1)
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chromedp.ListenTarget(lctx, func(ev interface{}) {
+		if e, ok := ev.(*input.EventDragIntercepted); ok {
+			select {
+			case intercepted <- e.Data:
+			default:
+			}
+		}
+	})
+
+	if err := mouseDown(ctx, fromX, fromY); err != nil {
+		return err
+	}
+	// Release the button wherever the pointer got to if the gesture fails,
+	// so the page is not left with a stuck button
+	lastX, lastY := fromX, fromY
+	defer func() {
+		if err != nil {
+			mouseUp(context.WithoutCancel(ctx), lastX, lastY)
+		}
+	}()
+	for i := 1; i <= dragSteps; i++ {
+		x := fromX + (toX-fromX)*float64(i)/dragSteps
+		y := fromY + (toY-fromY)*float64(i)/dragSteps
+		if err := input.DispatchMouseEvent(input.MouseMoved, x, y).
+			WithButton(input.Left).
+			WithButtons(1).
+			Do(ctx); err != nil {
+			return fmt.Errorf("mouse move failed: %w", err)
+		}
+		lastX, lastY = x, y
+	}
+
+	var data *input.DragData
+	select {
+	case data = <-intercepted:
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if data != nil {
+		for _, typ := range []input.DispatchDragEventType{input.DragEnter, input.DragOver, input.Drop} {
+			if err := input.DispatchDragEvent(typ, toX, toY, data).Do(ctx); err != nil {
+				return fmt.Errorf("drag event %s failed: %w", typ, err)
+			}
+		}
+	}
+	return mouseUp(ctx, toX, toY)
+}
+
+// queryNode returns the backend node of the first element matching a CSS
+// selector, which may pierce frames and shadow roots with >>>.
+func queryNode(ctx context.Context, selector string) (cdp.BackendNodeID, error) {
+	if strings.Contains(selector, piercer) {
+		return queryPiercing(ctx, selector)
+	}
+	var nodes []*cdp.Node
+	if err := chromedp.Nodes(selector, &nodes, chromedp.ByQuery, chromedp.AtLeast(0)).Do(ctx); err != nil {
+		return 0, fmt.Errorf("query %s failed: %w", selector, err)
+	}
+	if len(nodes) == 0 {
+		return 0, fmt.Errorf("element not found: %s", selector)
+	}
+	return nodes[0].BackendNodeID, nil
+}
+
+// nodeCenter scrolls a node into view and returns the centre of its first
+// content quad in viewport coordinates.
+func nodeCenter(ctx context.Context, backendID cdp.BackendNodeID) (float64, float64, error) {
+	if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(backendID).Do(ctx); err != nil {
+		return 0, 0, fmt.Errorf("scroll into view failed: %w", err)
+	}
+	return quadCenter(ctx, backendID)
+}
+
+// quadCenter returns the centre of a node's first content quad without scrolling.
+func quadCenter(ctx context.Context, backendID cdp.BackendNodeID) (float64, float64, error) {
+	quads, err := dom.GetContentQuads().WithBackendNodeID(backendID).Do(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not get element position: %w", err)
+	}
+	if len(quads) == 0 || len(quads[0]) < 8 {
+		return 0, 0, fmt.Errorf("element is not visible")
+	}
+	q := quads[0]
+	x := (q[0] + q[2] + q[4] + q[6]) / 4
+	y := (q[1] + q[3] + q[5] + q[7]) / 4
+	return x, y, nil
+}
@@ -0,0 +1,142 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// maxBlockedReports caps the blocked requests kept between two drains.
+const maxBlockedReports = 50
+
+// blockedByDomain is the count key for requests refused by a domain policy.
+const blockedByDomain = "domain_policy"
+
+// BlockedRequest is a request the browser refused because of a policy.
+type BlockedRequest struct {
+	URL          string
+	ResourceType string
+	Reason       string
+}
+
+// intercepting reports whether any policy needs requests paused for a
+// decision. b.mu must be held.
+func (b *Browser) intercepting() bool {
+	return len(b.domainPolicies) > 0 || !b.resourcePolicy.empty() || b.handlesAuth()
+}
+
+// applyInterception turns request interception on or off in every attached
+// tab to match the current policies.
+func (b *Browser) applyInterception() error {
+	b.mu.Lock()
+	var ctxs []context.Context
+	for _, t := range b.tabs {
+		if t.ctx != nil && !t.closed {
+			ctxs = append(ctxs, t.ctx)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, ctx := range ctxs {
+		if err := b.enableInterception(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableInterception applies the interception setting to one tab.
+func (b *Browser) enableInterception(ctx context.Context) error {
+	b.mu.Lock()
+	on, auth := b.intercepting(), b.handlesAuth()
+	b.mu.Unlock()
+
+	var action chromedp.Action = fetch.Disable()
+	if on {
+		action = fetch.Enable().
+			WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}).
+			WithHandleAuthRequests(auth)
+	}
+	if err := chromedp.Run(ctx, action); err != nil {
+		return fmt.Errorf("configuring request interception failed: %w", err)
+	}
+	return nil
+}
+
+// onRequestPaused fails requests a policy forbids and lets the rest through.
+// Domain policy blocks are reported to the agent; resource blocks are only
+// counted.
+func (b *Browser) onRequestPaused(ctx context.Context, ev *fetch.EventRequestPaused) {
+	b.mu.Lock()
+	key := ""
+	if reason := b.domainBlockReason(ev.Request.URL); reason != "" {
+		key = blockedByDomain
+		if len(b.blockedRequests) < maxBlockedReports {
+			b.blockedRequests = append(b.blockedRequests, BlockedRequest{
+				URL:          ev.Request.URL,
+				ResourceType: string(ev.ResourceType),
+				Reason:       reason,
+			})
+		}
+	} else {
+		key = b.resourcePolicy.block(ev.Request.URL, ev.ResourceType)
+	}
+	if key != "" {
+		if b.blockedCounts == nil {
+			b.blockedCounts = make(map[string]int)
+		}
+		b.blockedCounts[key]++
+	}
+	if b.handlesAuth() && ev.NetworkID != "" {
+		if b.authRequests == nil {
+			b.authRequests = make(map[network.RequestID]fetch.RequestID)
+		}
+		b.authRequests[ev.NetworkID] = ev.RequestID
+	}
+	b.mu.Unlock()
+
+	// Handlers must not issue commands synchronously
+	go func() {
+		var action chromedp.Action = fetch.ContinueRequest(ev.RequestID)
+		if key != "" {
+			action = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient)
+		}
+		if err := chromedp.Run(ctx, action); err != nil && ctx.Err() == nil {
+			log.Printf("WARNING: could not resume request %s: %v", ev.Request.URL, err)
+		}
+	}()
+}
+
+// BlockedRequests returns the requests refused since the previous call.
+func (b *Browser) BlockedRequests() []BlockedRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	blocked := b.blockedRequests
+	b.blockedRequests = nil
+	return blocked
+}
+
+// DescribeBlocked renders blocked requests for the prompt, page loads first.
+func DescribeBlocked(blocked []BlockedRequest) string {
+	if len(blocked) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	others := 0
+	for _, r := range blocked {
+		if r.ResourceType != string(network.ResourceTypeDocument) {
+			others++
+			continue
+		}
+		fmt.Fprintf(&sb, "Page load of %s was blocked: %s\n", r.URL, r.Reason)
+	}
+	if others > 0 {
+		fmt.Fprintf(&sb, "%d other requests (scripts, images, API calls) were blocked by the same policy\n", others)
+	}
+	return sb.String()
+}
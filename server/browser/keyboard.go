@@ -0,0 +1,242 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// keyAliases maps the spellings models commonly use to DOM key names.
+var keyAliases = map[string]string{
+	"ctrl":     "Control",
+	"control":  "Control",
+	"cmd":      "Meta",
+	"command":  "Meta",
+	"meta":     "Meta",
+	"win":      "Meta",
+	"option":   "Alt",
+	"alt":      "Alt",
+	"shift":    "Shift",
+	"esc":      "Escape",
+	"return":   "Enter",
+	"del":      "Delete",
+	"up":       "ArrowUp",
+	"down":     "ArrowDown",
+	"left":     "ArrowLeft",
+	"right":    "ArrowRight",
+	"pgup":     "PageUp",
+	"pgdn":     "PageDown",
+	"space":    " ",
+	"spacebar": " ",
+}
+
+var modifierBits = map[string]input.Modifier{
+	"Alt":     input.ModifierAlt,
+	"Control": input.ModifierCtrl,
+	"Meta":    input.ModifierMeta,
+	"Shift":   input.ModifierShift,
+}
+
+// namedKeys indexes the kb key table by lower-cased DOM key name ("enter", "arrowdown", "f5").
+var namedKeys = func() map[string]rune {
+	m := make(map[string]rune)
+	for r, k := range kb.Keys {
+		if utf8.RuneCountInString(k.Key) > 1 {
+			name := strings.ToLower(k.Key)
+			// Prefer the left-hand variant of duplicated keys such as Control
+			if existing, ok := m[name]; !ok || r < existing {
+				m[name] = r
+			}
+		}
+	}
+	return m
+}()
+
+// lookupKey resolves a key name or a single character to its kb rune.
+func lookupKey(name string) (rune, string, error) {
+	if alias, ok := keyAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return r, name, nil
+	}
+	if r, ok := namedKeys[strings.ToLower(name)]; ok {
+		return r, kb.Keys[r].Key, nil
+	}
+	return 0, "", fmt.Errorf("unknown key %q", name)
+}
+
+// keyEvents builds the key event sequence for one key pressed while the
+// given modifiers are held.
+func keyEvents(r rune, mods input.Modifier) []*input.DispatchKeyEventParams {
+	events := kb.Encode(r)
+	withCommand := mods&(input.ModifierCtrl|input.ModifierAlt|input.ModifierMeta) != 0
+
+	out := make([]*input.DispatchKeyEventParams, 0, len(events))
+	for _, ev := range events {
+		// A char event would insert text instead of triggering the shortcut
+		if withCommand && ev.Type == input.KeyChar {
+			continue
+		}
+		ev.Modifiers |= mods
+		out = append(out, ev)
+	}
+	return out
+}
+
+// PressKey presses a single key such as "Enter", "Escape", "Tab", "ArrowDown"
+// or a printable character on the focused element.
+func (b *Browser) PressKey(key string) error {
+	return b.Hotkey(key)
+}
+
+// Hotkey presses a key combination such as "Control+A", "Shift+Tab" or
+// "Meta+Enter" on the focused element. A combination without modifiers is a
+// plain key press.
+func (b *Browser) Hotkey(combo string) error {
+	return b.runKeys(combo, nil)
+}
+
+// HotkeyOn focuses the target element, then presses the key combination.
+func (b *Browser) HotkeyOn(t Target, combo string) error {
+	return b.runKeys(combo, func(ctx context.Context) error {
+		id, err := b.resolve(ctx, t)
+		if err != nil {
+			return err
+		}
+		if err := dom.Focus().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("%s is not focusable: %w", t, err)
+		}
+		return nil
+	})
+}
+
+func (b *Browser) runKeys(combo string, focus func(ctx context.Context) error) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if focus != nil {
+			if err := focus(ctx); err != nil {
+				return err
+			}
+		}
+		return dispatchCombo(ctx, combo)
+	}))
+}
+
+// keyCombo is a parsed "Mod+Mod+Key" combination.
+type keyCombo struct {
+	modifiers []rune
+	names     []string // DOM names of the modifiers
+	mods      input.Modifier
+	key       rune
+	name      string
+}
+
+// parseCombo parses a key combination. "+" on its own or as the final key
+// ("Control++") is the plus key; any other empty key, as in "Control+", is an
+// error rather than a dropped modifier.
+func parseCombo(combo string) (keyCombo, error) {
+	var c keyCombo
+	combo = strings.TrimSpace(combo)
+	if combo == "" {
+		return c, fmt.Errorf("no key given")
+	}
+
+	var parts []string
+	switch {
+	case combo == "+":
+		parts = []string{"+"}
+	case strings.HasSuffix(combo, "++"):
+		parts = append(strings.Split(combo[:len(combo)-2], "+"), "+")
+	default:
+		parts = strings.Split(combo, "+")
+	}
+	for i, part := range parts {
+		if parts[i] = strings.TrimSpace(part); parts[i] == "" {
+			return c, fmt.Errorf("empty key in %q", combo)
+		}
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		r, name, err := lookupKey(part)
+		if err != nil {
+			return c, err
+		}
+		bit, isModifier := modifierBits[name]
+		if !isModifier {
+			return c, fmt.Errorf("%q is not a modifier key in %q", part, combo)
+		}
+		c.mods |= bit
+		c.modifiers = append(c.modifiers, r)
+		c.names = append(c.names, name)
+	}
+	key, name, err := lookupKey(parts[len(parts)-1])
+	if err != nil {
+		return c, err
+	}
+	// In a shortcut the letter case follows Shift, so "Control+A" is Ctrl+a
+	if c.mods != 0 && unicode.IsLetter(key) && key < utf8.RuneSelf {
+		if c.mods&input.ModifierShift != 0 {
+			key = unicode.ToUpper(key)
+		} else {
+			key = unicode.ToLower(key)
+		}
+	}
+	c.key, c.name = key, name
+	return c, nil
+}
+
+// dispatchCombo holds the modifiers of a "Mod+Mod+Key" combination down in
+// order, presses the key and releases the modifiers in reverse order. The
+// whole combination is checked before any key goes down, and held modifiers
+// are released even when a later key fails.
+func dispatchCombo(ctx context.Context, combo string) (err error) {
+	c, err := parseCombo(combo)
+	if err != nil {
+		return err
+	}
+
+	var held []rune
+	defer func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			for _, ev := range keyEvents(held[i], c.mods) {
+				if ev.Type != input.KeyUp {
+					continue
+				}
+				if upErr := ev.Do(ctx); upErr != nil && err == nil {
+					err = fmt.Errorf("key release failed: %w", upErr)
+				}
+			}
+		}
+	}()
+
+	var down input.Modifier
+	for i, r := range c.modifiers {
+		down |= modifierBits[c.names[i]]
+		for _, ev := range keyEvents(r, down) {
+			if ev.Type == input.KeyDown {
+				if err := ev.Do(ctx); err != nil {
+					return fmt.Errorf("key %s down failed: %w", c.names[i], err)
+				}
+			}
+		}
+		held = append(held, r)
+	}
+	for _, ev := range keyEvents(c.key, c.mods) {
+		if err := ev.Do(ctx); err != nil {
+			return fmt.Errorf("key %s failed: %w", c.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chromedp/cdproto/input"
+)
+
+func TestParseCombo(t *testing.T) {
+	tests := []struct {
+		combo   string
+		names   []string
+		mods    input.Modifier
+		key     rune
+		wantErr bool
+	}{
+		{combo: "Enter", key: '\r'},
+		{combo: " a ", key: 'a'},
+		{combo: "Control+A", names: []string{"Control"}, mods: input.ModifierCtrl, key: 'a'},
+		{combo: "ctrl+shift+a", names: []string{"Control", "Shift"}, mods: input.ModifierCtrl | input.ModifierShift, key: 'A'},
+		{combo: "Shift + Tab", names: []string{"Shift"}, mods: input.ModifierShift, key: '\t'},
+		{combo: "cmd+Enter", names: []string{"Meta"}, mods: input.ModifierMeta, key: '\r'},
+		{combo: "+", key: '+'},
+		{combo: "Control++", names: []string{"Control"}, mods: input.ModifierCtrl, key: '+'},
+		{combo: "Control+Shift++", names: []string{"Control", "Shift"}, mods: input.ModifierCtrl | input.ModifierShift, key: '+'},
+		{combo: "", wantErr: true},
+		{combo: "Control+", wantErr: true},
+		{combo: "Control+ ", wantErr: true},
+		{combo: "Control++Shift", wantErr: true},
+		{combo: "++", wantErr: true},
+		{combo: "a+b", wantErr: true},
+		{combo: "Control+Nope", wantErr: true},
+	}
+	for _, tt := range tests {
+		c, err := parseCombo(tt.combo)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCombo(%q) = %+v, want an error", tt.combo, c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCombo(%q): %v", tt.combo, err)
+			continue
+		}
+		if c.key != tt.key || c.mods != tt.mods || !slices.Equal(c.names, tt.names) {
+			t.Errorf("parseCombo(%q) = key %q mods %v modifiers %v, want key %q mods %v modifiers %v",
+				tt.combo, c.key, c.mods, c.names, tt.key, tt.mods, tt.names)
+		}
+	}
+}
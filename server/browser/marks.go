@@ -0,0 +1,446 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	markAttribute   = "data-zenact-mark"
+	markObjectGroup = "zenact-marks"
+)
+
+// MarkedElement is an interactive element that was assigned a numeric label
+// during observation. The same element keeps its number for as long as it
+// stays in the document.
+type MarkedElement struct {
+	Index   int      `json:"index"`
+	Tag     string   `json:"tag"`
+	Role    string   `json:"role"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type,omitempty"`
+	Value   string   `json:"value,omitempty"`
+	Checked *bool    `json:"checked,omitempty"`
+	Options []string `json:"options,omitempty"`
+	Bounds  []int    `json:"bounds"`
+	Frame   string   `json:"frame,omitempty"` // owner iframe selector for elements in child frames
+}
+
+// markScript numbers the visible interactive elements of one frame, including
+// those inside open shadow roots. Elements keep a number they already carry
+// unless another element claimed it; new numbers continue from seq.
+const markScript = `function(used, seq) {
+	const selector = 'a[href], button, input:not([type="hidden"]), select, textarea, summary, [contenteditable=""], [contenteditable="true"], [onclick], [tabindex]:not([tabindex="-1"]), ' +
+		'[role="button"], [role="link"], [role="checkbox"], [role="radio"], [role="tab"], [role="menuitem"], [role="option"], [role="switch"], [role="combobox"], [role="textbox"], [role="slider"]';
+	used = new Set(used);
+
+	function implicitRole(el) {
+		const explicit = el.getAttribute('role');
+		if (explicit) return explicit;
+		const type = (el.getAttribute('type') || '').toLowerCase();
+		switch (el.tagName) {
+			case 'A': return 'link';
+			case 'BUTTON': return 'button';
+			case 'SELECT': return 'combobox';
+			case 'TEXTAREA': return 'textbox';
+			case 'SUMMARY': return 'button';
+			case 'INPUT':
+				if (type === 'button' || type === 'submit' || type === 'reset' || type === 'image') return 'button';
+				if (type === 'checkbox') return 'checkbox';
+				if (type === 'radio') return 'radio';
+				if (type === 'range') return 'slider';
+				return 'textbox';
+			default:
+				return el.isContentEditable ? 'textbox' : 'generic';
+		}
+	}
+
+	function accessibleName(el) {
+		const label = el.getAttribute('aria-label');
+		if (label) return label;
+		const labelledBy = el.getAttribute('aria-labelledby');
+		if (labelledBy) {
+			const root = el.getRootNode();
+			const text = labelledBy.split(' ').map(id => root.getElementById ? root.getElementById(id) : document.getElementById(id)).filter(Boolean).map(n => n.textContent).join(' ').trim();
+			if (text) return text;
+		}
+		if (el.labels && el.labels.length) return el.labels[0].textContent.trim();
+		const text = (el.innerText || el.textContent || '').replace(/\s+/g, ' ').trim();
+		if (text) return text;
+		return el.getAttribute('placeholder') || el.getAttribute('title') || el.getAttribute('alt') || el.getAttribute('name') || '';
+	}
+
+	// Candidates in document order, descending into open shadow roots
+	const candidates = [];
+	(function collect(root) {
+		for (const el of root.querySelectorAll('*')) {
+			if (el.matches(selector)) candidates.push(el);
+			if (el.shadowRoot) collect(el.shadowRoot);
+		}
+	})(document);
+
+	const vw = window.innerWidth, vh = window.innerHeight;
+	const active = [];
+	const results = [];
+
+	for (const el of candidates) {
+		if (results.length >= 150) break;
+		const rect = el.getBoundingClientRect();
+		if (rect.width < 4 || rect.height < 4) continue;
+		if (rect.bottom < 0 || rect.right < 0 || rect.top > vh || rect.left > vw) continue;
+		const style = getComputedStyle(el);
+		if (style.visibility === 'hidden' || style.display === 'none' || parseFloat(style.opacity) === 0) continue;
+
+		const cx = Math.min(Math.max(rect.left + rect.width / 2, 0), vw - 1);
+		const cy = Math.min(Math.max(rect.top + rect.height / 2, 0), vh - 1);
+		const hit = el.getRootNode().elementFromPoint(cx, cy);
+		if (hit && hit !== el && !el.contains(hit) && !hit.contains(el)) continue;
+
+		let index = parseInt(el.getAttribute('` + markAttribute + `'), 10);
+		if (!index || used.has(index)) {
+			index = ++seq;
+			el.setAttribute('` + markAttribute + `', String(index));
+		}
+		used.add(index);
+
+		const type = el.getAttribute('type') || '';
+		let value = '';
+		if ('value' in el && el.tagName !== 'BUTTON' && el.tagName !== 'LI') {
+			value = type === 'password' && el.value ? '••••' : String(el.value || '');
+		}
+
+		let checked = null;
+		if (el.tagName === 'INPUT' && (type === 'checkbox' || type === 'radio')) {
+			checked = el.checked;
+		} else if (el.getAttribute('aria-checked') !== null) {
+			checked = el.getAttribute('aria-checked') === 'true';
+		}
+
+		let options = null;
+		if (el.tagName === 'SELECT') {
+			options = Array.from(el.options).slice(0, 25).map(o => o.label.trim());
+			const selected = el.options[el.selectedIndex];
+			value = selected ? selected.label.trim() : '';
+		}
+
+		active.push(el);
+		results.push({
+			index: index,
+			tag: el.tagName.toLowerCase(),
+			role: implicitRole(el),
+			name: accessibleName(el).substring(0, 80),
+			type: type,
+			value: value.substring(0, 80),
+			checked: checked,
+			options: options,
+			bounds: [Math.round(rect.left), Math.round(rect.top), Math.round(rect.width), Math.round(rect.height)]
+		});
+	}
+
+	window.__zenactActive = active;
+	return { seq: seq, viewport: [vw, vh], elements: results };
+}`
+
+// drawMarksScript draws the numbered boxes as an overlay on the main frame so
+// labels of elements inside iframes are captured as well.
+const drawMarksScript = `function(boxes) {
+	const colors = ['#e6194b', '#3cb44b', '#4363d8', '#f58231', '#911eb4', '#008080'];
+	const old = document.getElementById('__zenact_marks');
+	if (old) old.remove();
+
+	const layer = document.createElement('div');
+	layer.id = '__zenact_marks';
+	layer.style.cssText = 'position:fixed;left:0;top:0;width:100%;height:100%;pointer-events:none;z-index:2147483647;';
+	for (const [index, left, top, width, height] of boxes) {
+		const color = colors[index % colors.length];
+		const box = document.createElement('div');
+		box.style.cssText = 'position:fixed;box-sizing:border-box;border:2px solid ' + color + ';' +
+			'left:' + left + 'px;top:' + top + 'px;width:' + width + 'px;height:' + height + 'px;';
+		const tag = document.createElement('span');
+		tag.textContent = String(index);
+		tag.style.cssText = 'position:absolute;left:-2px;top:' + (top < 16 ? '0' : '-16px') + ';' +
+			'background:' + color + ';color:#fff;font:bold 11px/14px monospace;padding:0 3px;border-radius:2px;';
+		box.appendChild(tag);
+		layer.appendChild(box);
+	}
+	document.documentElement.appendChild(layer);
+}`
+
+type markResult struct {
+	Seq      int             `json:"seq"`
+	Viewport []float64       `json:"viewport"`
+	Elements []MarkedElement `json:"elements"`
+}
+
+// MarkElements labels the visible interactive elements of every frame with
+// numbers, draws the labels as an overlay so they appear in the next
+// screenshot, and remembers the backend node behind each number for
+// element-targeted actions.
+func (b *Browser) MarkElements() ([]MarkedElement, error) {
+	var elements []MarkedElement
+	marks := make(map[int]cdp.BackendNodeID)
+	b.mu.Lock()
+	seq := b.markSeq
+	b.mu.Unlock()
+
+	err := chromedp.Run(b.activeCtx(), chromedp.ActionFunc(func(ctx context.Context) error {
+		frames, err := b.frameContexts(ctx)
+		if err != nil {
+			return err
+		}
+
+		used := []int{}
+		var vw, vh float64
+		for i := range frames {
+			fc := &frames[i]
+			var res markResult
+			if err := b.callInFrame(ctx, fc, markScript, &res, used, seq); err != nil {
+				if fc.selector == "" {
+					return err
+				}
+				// Child frames can navigate or detach mid-snapshot
+				continue
+			}
+			seq = res.Seq
+			if fc.selector == "" && len(res.Viewport) == 2 {
+				vw, vh = res.Viewport[0], res.Viewport[1]
+			}
+
+			for _, el := range res.Elements {
+				used = append(used, el.Index)
+				if fc.selector != "" {
+					el.Frame = fc.selector
+					el.Bounds[0] += int(fc.offsetX)
+					el.Bounds[1] += int(fc.offsetY)
+					// Parts of a frame scrolled out of the main viewport are not visible
+					if float64(el.Bounds[0]) > vw || float64(el.Bounds[1]) > vh || el.Bounds[0]+el.Bounds[2] < 0 || el.Bounds[1]+el.Bounds[3] < 0 {
+						continue
+					}
+				}
+				elements = append(elements, el)
+			}
+			if err := resolveMarked(ctx, fc.world, marks); err != nil {
+				return err
+			}
+		}
+		// Numbers of elements scrolled out of view stay unresolved, so
+		// actions cannot target what the model was not shown
+		shown := make(map[int]cdp.BackendNodeID, len(elements))
+		for _, el := range elements {
+			if id, ok := marks[el.Index]; ok {
+				shown[el.Index] = id
+			}
+		}
+		marks = shown
+
+		boxes := make([][]int, len(elements))
+		for i, el := range elements {
+			boxes[i] = append([]int{el.Index}, el.Bounds...)
+		}
+		return b.callInFrame(ctx, &frames[0], drawMarksScript, nil, boxes)
+	}))
+
+	// Numbers handed out stay taken even if marking failed part way
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.markSeq = seq
+	if err != nil {
+		return nil, fmt.Errorf("marking elements failed: %w", err)
+	}
+	b.marks = marks
+	return elements, nil
+}
+
+// resolveMarked maps the numbers of the elements a frame's mark script just
+// labelled to their backend nodes.
+func resolveMarked(ctx context.Context, world runtime.ExecutionContextID, marks map[int]cdp.BackendNodeID) error {
+	obj, exc, err := runtime.Evaluate("window.__zenactActive || []").
+		WithContextID(world).
+		WithObjectGroup(markObjectGroup).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exc != nil {
+		return exc
+	}
+	defer runtime.ReleaseObjectGroup(markObjectGroup).Do(ctx)
+
+	props, _, _, _, err := runtime.GetProperties(obj.ObjectID).WithOwnProperties(true).Do(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range props {
+		if p.Value == nil || p.Value.Subtype != runtime.SubtypeNode || p.Value.ObjectID == "" {
+			continue
+		}
+		node, err := dom.DescribeNode().WithObjectID(p.Value.ObjectID).Do(ctx)
+		if err != nil {
+			continue
+		}
+		index, err := strconv.Atoi(node.AttributeValue(markAttribute))
+		if err != nil {
+			continue
+		}
+		marks[index] = node.BackendNodeID
+	}
+	return nil
+}
+
+// ClearMarks removes the label overlay. Element numbers stay attached to the
+// DOM so they remain stable across observations.
+func (b *Browser) ClearMarks() error {
+	return chromedp.Run(b.activeCtx(), chromedp.Evaluate(`(() => { const l = document.getElementById('__zenact_marks'); if (l) l.remove(); })()`, nil))
+}
+
+func (b *Browser) markedNode(index int) (cdp.BackendNodeID, error) {
+	b.mu.Lock()
+	id, ok := b.marks[index]
+	b.mu.Unlock()
+	if !ok {
+		return 0, fmt.Errorf("element %d not found in the current page snapshot", index)
+	}
+	return id, nil
+}
+
+// Target identifies an element either by its mark number or by CSS selector.
+type Target struct {
+	Element  int
+	Selector string
+}
+
+func (t Target) String() string {
+	if t.Element > 0 {
+		return fmt.Sprintf("element %d", t.Element)
+	}
+	return t.Selector
+}
+
+// resolve returns the backend node the target refers to.
+func (b *Browser) resolve(ctx context.Context, t Target) (cdp.BackendNodeID, error) {
+	if t.Element > 0 {
+		return b.markedNode(t.Element)
+	}
+	if t.Selector == "" {
+		return 0, fmt.Errorf("an element number or selector is required")
+	}
+	return queryNode(ctx, t.Selector)
+}
+
+// ClickElement clicks the element labelled with the given number.
+func (b *Browser) ClickElement(index int) error {
+	if _, err := b.markedNode(index); err != nil {
+		return err
+	}
+	return b.clickTarget(Target{Element: index})
+}
+
+// clickTarget clicks the centre of the target with trusted mouse input, which
+// also works for elements inside iframes and shadow roots.
+func (b *Browser) clickTarget(t Target) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := b.resolve(ctx, t)
+		if err != nil {
+			return err
+		}
+		x, y, err := nodeCenter(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		return mouseClick(ctx, x, y, 1)
+	}))
+}
+
+// TypeElement focuses the element labelled with the given number, clears it
+// and inserts text.
+func (b *Browser) TypeElement(index int, text string) error {
+	if _, err := b.markedNode(index); err != nil {
+		return err
+	}
+	return b.typeTarget(Target{Element: index}, text)
+}
+
+func (b *Browser) typeTarget(t Target, text string) error {
+	timeoutCtx, cancel := context.WithTimeout(b.activeCtx(), 10*time.Second)
+	defer cancel()
+
+	return chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := b.resolve(ctx, t)
+		if err != nil {
+			return err
+		}
+		if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("%s: scroll into view failed: %w", t, err)
+		}
+		if err := dom.Focus().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("%s is not focusable: %w", t, err)
+		}
+		obj, err := dom.ResolveNode().WithBackendNodeID(id).WithObjectGroup(markObjectGroup).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+		defer runtime.ReleaseObjectGroup(markObjectGroup).Do(ctx)
+
+		clear := `function() {
+			if ('value' in this) {
+				this.value = '';
+				this.dispatchEvent(new Event('input', { bubbles: true }));
+			} else if (this.isContentEditable) {
+				this.textContent = '';
+			}
+		}`
+		if _, exc, err := runtime.CallFunctionOn(clear).WithObjectID(obj.ObjectID).Do(ctx); err != nil {
+			return err
+		} else if exc != nil {
+			return exc
+		}
+		return input.InsertText(text).Do(ctx)
+	}))
+}
+
+// DescribeElements renders marked elements as a compact list for the model.
+func DescribeElements(elements []MarkedElement) string {
+	var sb strings.Builder
+	for _, el := range elements {
+		fmt.Fprintf(&sb, "[%d] %s", el.Index, el.Role)
+		if el.Role != el.Tag {
+			fmt.Fprintf(&sb, " <%s>", el.Tag)
+		}
+		if el.Type != "" {
+			fmt.Fprintf(&sb, " type=%s", el.Type)
+		}
+		if el.Name != "" {
+			fmt.Fprintf(&sb, " %q", el.Name)
+		}
+		if el.Value != "" {
+			fmt.Fprintf(&sb, " value=%q", el.Value)
+		}
+		if el.Checked != nil {
+			if *el.Checked {
+				sb.WriteString(" checked")
+			} else {
+				sb.WriteString(" unchecked")
+			}
+		}
+		if len(el.Options) > 0 {
+			fmt.Fprintf(&sb, " options=[%s]", strings.Join(el.Options, " | "))
+		}
+		if el.Frame != "" {
+			fmt.Fprintf(&sb, " in frame %s", el.Frame)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,348 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// NetworkCaptureOptions control which requests are recorded and whether
+// response bodies are kept.
+type NetworkCaptureOptions struct {
+	Enabled      bool
+	Bodies       bool
+	MaxBodyBytes int64    // bodies larger than this are left out
+	MIMETypes    []string // prefixes such as "text/" or "application/json"; empty keeps every type
+
+	// Headers whose values are replaced in recorded entries, besides the
+	// credential headers that are always redacted
+	RedactHeaders []string
+}
+
+// sensitiveHeaders carry credentials and are never recorded.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const redacted = "[redacted]"
+
+// NetworkEntry is one request/response exchange. A redirect chain yields one
+// entry per hop.
+type NetworkEntry struct {
+	RequestID      string
+	ResourceType   string
+	Method         string
+	URL            string
+	RequestHeaders map[string]string
+	PostData       string
+	StartedAt      time.Time
+
+	Status          int64
+	StatusText      string
+	Protocol        string
+	ResponseHeaders map[string]string
+	MIMEType        string
+	RemoteIP        string
+	RedirectURL     string
+	FromCache       bool
+	TransferSize    int64 // bytes received over the network, -1 if unknown
+
+	Body         string
+	BodyEncoding string // "base64" for binary bodies
+	BodySize     int64  // decoded body size, -1 if unknown
+
+	Timings NetworkTimings
+	Error   string
+
+	started  time.Time // monotonic timestamp of the request
+	timing   *network.ResourceTiming
+	finished bool
+	fetching bool // response body still being read
+}
+
+// NetworkTimings are the phases of an exchange in milliseconds, -1 where a
+// phase did not apply.
+type NetworkTimings struct {
+	Blocked float64
+	DNS     float64
+	Connect float64
+	SSL     float64
+	Send    float64
+	Wait    float64
+	Receive float64
+	Total   float64
+}
+
+// SetNetworkCapture sets which traffic is recorded from now on.
+func (b *Browser) SetNetworkCapture(opts NetworkCaptureOptions) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.netCapture = opts
+}
+
+// NetworkEntries returns the exchanges completed since the previous call,
+// oldest first. Requests still in flight are returned by a later call.
+func (b *Browser) NetworkEntries() []NetworkEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var done []NetworkEntry
+	kept := b.netLog[:0]
+	for _, e := range b.netLog {
+		if e.finished && !e.fetching {
+			entry := *e
+			entry.RequestHeaders = redactHeaders(e.RequestHeaders, b.netCapture.RedactHeaders)
+			entry.ResponseHeaders = redactHeaders(e.ResponseHeaders, b.netCapture.RedactHeaders)
+			done = append(done, entry)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	b.netLog = kept
+	sort.SliceStable(done, func(i, j int) bool { return done[i].StartedAt.Before(done[j].StartedAt) })
+	return done
+}
+
+func (b *Browser) onNetworkRequest(ev *network.EventRequestWillBeSent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.netCapture.Enabled {
+		return
+	}
+
+	// A redirect reuses the request ID; the previous hop ends with the
+	// redirect response
+	if prev := b.netRequests[ev.RequestID]; prev != nil && ev.RedirectResponse != nil {
+		prev.setResponse(ev.RedirectResponse)
+		prev.RedirectURL = ev.Request.URL
+		prev.finish(monotonic(ev.Timestamp))
+		delete(b.netRequests, ev.RequestID)
+	}
+
+	e := &NetworkEntry{
+		RequestID:      string(ev.RequestID),
+		ResourceType:   string(ev.Type),
+		Method:         ev.Request.Method,
+		URL:            ev.Request.URL + ev.Request.URLFragment,
+		RequestHeaders: flattenHeaders(ev.Request.Headers),
+		StartedAt:      time.Now(),
+		TransferSize:   -1,
+		BodySize:       -1,
+		started:        monotonic(ev.Timestamp),
+	}
+	if ev.WallTime != nil {
+		e.StartedAt = ev.WallTime.Time()
+	}
+	if b.netCapture.Bodies {
+		e.PostData = postData(ev.Request.PostDataEntries, b.netCapture.MaxBodyBytes)
+	}
+	if b.netRequests == nil {
+		b.netRequests = make(map[network.RequestID]*NetworkEntry)
+	}
+	b.netRequests[ev.RequestID] = e
+	b.netLog = append(b.netLog, e)
+}
+
+func (b *Browser) onNetworkResponse(ev *network.EventResponseReceived) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e := b.netRequests[ev.RequestID]; e != nil {
+		e.setResponse(ev.Response)
+	}
+}
+
+// onNetworkFinished completes an exchange and starts reading its body when
+// the capture options ask for it.
+func (b *Browser) onNetworkFinished(ctx context.Context, ev *network.EventLoadingFinished) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.netRequests[ev.RequestID]
+	if e == nil {
+		return
+	}
+	delete(b.netRequests, ev.RequestID)
+	e.TransferSize = int64(ev.EncodedDataLength)
+	e.finish(monotonic(ev.Timestamp))
+
+	opts := b.netCapture
+	if !opts.Bodies || !bodyWanted(e.MIMEType, opts.MIMETypes) {
+		return
+	}
+	if opts.MaxBodyBytes > 0 && e.TransferSize > opts.MaxBodyBytes {
+		return
+	}
+	e.fetching = true
+
+	// Handlers must not issue commands synchronously
+	go func() {
+		var body []byte
+		err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			body, err = network.GetResponseBody(ev.RequestID).Do(ctx)
+			return err
+		}))
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		e.fetching = false
+		if err != nil {
+			return
+		}
+		e.BodySize = int64(len(body))
+		if opts.MaxBodyBytes > 0 && e.BodySize > opts.MaxBodyBytes {
+			return
+		}
+		e.Body, e.BodyEncoding = encodeBody(body)
+	}()
+}
+
+func (b *Browser) onNetworkFailed(ev *network.EventLoadingFailed) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.netRequests[ev.RequestID]
+	if e == nil {
+		return
+	}
+	delete(b.netRequests, ev.RequestID)
+	e.Error = ev.ErrorText
+	if ev.BlockedReason != "" {
+		e.Error = fmt.Sprintf("%s (%s)", ev.ErrorText, ev.BlockedReason)
+	}
+	e.finish(monotonic(ev.Timestamp))
+}
+
+func (e *NetworkEntry) setResponse(r *network.Response) {
+	e.Status = r.Status
+	e.StatusText = r.StatusText
+	e.Protocol = r.Protocol
+	e.ResponseHeaders = flattenHeaders(r.Headers)
+	e.MIMEType = r.MimeType
+	e.RemoteIP = r.RemoteIPAddress
+	e.FromCache = r.FromDiskCache || r.FromPrefetchCache || r.FromServiceWorker
+	e.timing = r.Timing
+	if len(r.RequestHeaders) > 0 {
+		// The headers actually sent, including cookies, when Chrome reports them
+		e.RequestHeaders = flattenHeaders(r.RequestHeaders)
+	}
+}
+
+// finish fills in the timings once the exchange ended at the monotonic time end.
+func (e *NetworkEntry) finish(end time.Time) {
+	e.finished = true
+	total := float64(end.Sub(e.started)) / float64(time.Millisecond)
+	if total < 0 {
+		total = 0
+	}
+	e.Timings = NetworkTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Total: total}
+
+	t := e.timing
+	if t == nil {
+		e.Timings.Wait = total
+		return
+	}
+	// ResourceTiming offsets are milliseconds after RequestTime
+	phase := func(start, end float64) float64 {
+		if start < 0 || end < 0 {
+			return -1
+		}
+		return end - start
+	}
+	requestTime := cdp.MonotonicTimeEpoch.Add(time.Duration(t.RequestTime * float64(time.Second)))
+	offset := float64(requestTime.Sub(e.started)) / float64(time.Millisecond)
+	for _, start := range []float64{t.DNSStart, t.ConnectStart, t.SendStart} {
+		if start >= 0 {
+			e.Timings.Blocked = offset + start
+			break
+		}
+	}
+	e.Timings.DNS = phase(t.DNSStart, t.DNSEnd)
+	e.Timings.Connect = phase(t.ConnectStart, t.ConnectEnd)
+	e.Timings.SSL = phase(t.SslStart, t.SslEnd)
+	e.Timings.Send = phase(t.SendStart, t.SendEnd)
+	e.Timings.Wait = phase(t.SendEnd, t.ReceiveHeadersEnd)
+	e.Timings.Receive = total - offset - t.ReceiveHeadersEnd
+	if e.Timings.Receive < 0 {
+		e.Timings.Receive = 0
+	}
+}
+
+// monotonic converts a CDP timestamp, which may be missing, to a time.
+func monotonic(t *cdp.MonotonicTime) time.Time {
+	if t == nil {
+		return time.Now()
+	}
+	return t.Time()
+}
+
+func flattenHeaders(h network.Headers) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// redactHeaders copies headers with the values of credential headers and of
+// extra names replaced.
+func redactHeaders(h map[string]string, extra []string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	names := append(append([]string(nil), sensitiveHeaders...), extra...)
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = v
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				out[k] = redacted
+				break
+			}
+		}
+	}
+	return out
+}
+
+// postData joins a request body from its entries, leaving it out when it
+// exceeds max bytes.
+func postData(entries []*network.PostDataEntry, max int64) string {
+	var sb strings.Builder
+	for _, entry := range entries {
+		raw, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			continue
+		}
+		sb.Write(raw)
+		if max > 0 && int64(sb.Len()) > max {
+			return ""
+		}
+	}
+	return sb.String()
+}
+
+// bodyWanted reports whether a MIME type matches one of the prefixes.
+func bodyWanted(mimeType string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	mimeType = strings.ToLower(mimeType)
+	for _, p := range prefixes {
+		if strings.HasPrefix(mimeType, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBody returns text bodies as is and binary ones base64-encoded.
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		extra   []string
+		want    map[string]string
+	}{
+		{name: "nil", headers: nil, want: nil},
+		{
+			name:    "credentials",
+			headers: map[string]string{"Authorization": "Bearer x", "cookie": "a=b", "Set-Cookie": "c=d", "Proxy-Authorization": "Basic y"},
+			want:    map[string]string{"Authorization": redacted, "cookie": redacted, "Set-Cookie": redacted, "Proxy-Authorization": redacted},
+		},
+		{
+			name:    "other headers kept",
+			headers: map[string]string{"Content-Type": "text/html", "Accept": "*/*"},
+			want:    map[string]string{"Content-Type": "text/html", "Accept": "*/*"},
+		},
+		{
+			name:    "extra names in any case",
+			headers: map[string]string{"x-api-key": "secret", "X-Trace": "1"},
+			extra:   []string{"X-API-Key"},
+			want:    map[string]string{"x-api-key": redacted, "X-Trace": "1"},
+		},
+	}
+	for _, tt := range tests {
+		before := maps.Clone(tt.headers)
+		got := redactHeaders(tt.headers, tt.extra)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: redactHeaders = %v, want %v", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(tt.headers, before) {
+			t.Errorf("%s: redactHeaders modified its input: %v", tt.name, tt.headers)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package browser
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// blockableTypes maps the resource type names accepted in configuration to
+// CDP resource types. Documents are never blocked by type.
+var blockableTypes = map[string]network.ResourceType{
+	"image":      network.ResourceTypeImage,
+	"media":      network.ResourceTypeMedia,
+	"font":       network.ResourceTypeFont,
+	"stylesheet": network.ResourceTypeStylesheet,
+	"script":     network.ResourceTypeScript,
+	"texttrack":  network.ResourceTypeTextTrack,
+	"prefetch":   network.ResourceTypePrefetch,
+	"manifest":   network.ResourceTypeManifest,
+	"ping":       network.ResourceTypePing,
+	"other":      network.ResourceTypeOther,
+}
+
+// blockedByList is the count key for requests refused by the host blocklist.
+const blockedByList = "blocklist"
+
+// ResourcePolicy drops requests that only add weight or clutter: whole
+// resource types such as images and fonts, and requests to hosts on a
+// blocklist of ad and tracker domains. Page loads are never dropped.
+type ResourcePolicy struct {
+	Types []string // names from blockableTypes
+	Hosts *HostList
+}
+
+// ValidateResourceTypes checks resource type names such as "image" or "font".
+func ValidateResourceTypes(names []string) error {
+	for _, name := range names {
+		if _, ok := blockableTypes[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown resource type %q (use image, media, font, stylesheet, script, texttrack, prefetch, manifest, ping or other)", name)
+		}
+	}
+	return nil
+}
+
+func (p ResourcePolicy) empty() bool {
+	return len(p.Types) == 0 && p.Hosts.Len() == 0
+}
+
+// block returns the count key of the rule that drops a request, or "".
+func (p ResourcePolicy) block(rawURL string, typ network.ResourceType) string {
+	if typ == network.ResourceTypeDocument {
+		return ""
+	}
+	for _, name := range p.Types {
+		if blockableTypes[strings.ToLower(name)] == typ {
+			return strings.ToLower(name)
+		}
+	}
+	if p.Hosts.Len() > 0 {
+		if u, err := url.Parse(rawURL); err == nil && p.Hosts.Contains(u.Hostname()) {
+			return blockedByList
+		}
+	}
+	return ""
+}
+
+// HostList is a set of blocked hosts. A listed host also blocks its subdomains.
+type HostList struct {
+	hosts map[string]bool
+}
+
+// LoadHostList reads a blocklist with one host per line. Hosts-file lines
+// ("0.0.0.0 ads.example.com"), Adblock host rules ("||ads.example.com^") and
+// # or ! comments are understood; other rules are skipped.
+func LoadHostList(path string) (*HostList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer f.Close()
+
+	l := &HostList{hosts: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if rule, ok := strings.CutPrefix(line, "||"); ok {
+			host, ok := strings.CutSuffix(rule, "^")
+			if !ok || strings.ContainsAny(host, "/*$") {
+				continue
+			}
+			line = host
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		host := strings.ToLower(fields[len(fields)-1])
+		if host == "localhost" || strings.ContainsAny(host, "/*") {
+			continue
+		}
+		l.hosts[host] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	return l, nil
+}
+
+// Len returns the number of listed hosts; a nil list is empty.
+func (l *HostList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.hosts)
+}
+
+// Contains reports whether host or one of its parent domains is listed.
+func (l *HostList) Contains(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for host != "" {
+		if l.hosts[host] {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false
+}
+
+// SetResourcePolicy sets which resources every tab drops from now on.
+func (b *Browser) SetResourcePolicy(p ResourcePolicy) error {
+	b.mu.Lock()
+	b.resourcePolicy = p
+	b.mu.Unlock()
+	return b.applyInterception()
+}
+
+// BlockedCounts returns how many requests each rule dropped since the
+// previous call, keyed by resource type, "blocklist" or "domain_policy".
+func (b *Browser) BlockedCounts() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := b.blockedCounts
+	b.blockedCounts = nil
+	return counts
+}
@@ -0,0 +1,106 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ScreencastOptions configures the live view of the active tab.
+type ScreencastOptions struct {
+	FPS     int // upper bound; Chrome only sends frames when the page repaints
+	Quality int // JPEG quality, 1-100
+}
+
+// Frame is one JPEG screencast image of the active tab.
+type Frame struct {
+	Data   string // base64
+	Width  int    // viewport size in CSS pixels
+	Height int
+	Time   time.Time
+}
+
+// StartScreencast streams frames of the active tab to onFrame, following tab
+// switches. onFrame runs on the event goroutine and must not block.
+func (b *Browser) StartScreencast(opts ScreencastOptions, onFrame func(Frame)) error {
+	if opts.FPS <= 0 {
+		return fmt.Errorf("screencast needs a positive frame rate")
+	}
+	b.mu.Lock()
+	b.screencast = opts
+	b.onFrame = onFrame
+	b.mu.Unlock()
+	return b.startScreencast(b.activeCtx())
+}
+
+// startScreencast starts the screencast in one tab if it is enabled.
+func (b *Browser) startScreencast(ctx context.Context) error {
+	b.mu.Lock()
+	opts, on := b.screencast, b.onFrame != nil
+	b.mu.Unlock()
+	if !on {
+		return nil
+	}
+	err := chromedp.Run(ctx, page.StartScreencast().
+		WithFormat(page.ScreencastFormatJpeg).
+		WithQuality(int64(opts.Quality)).
+		WithEveryNthFrame(1))
+	if err != nil {
+		return fmt.Errorf("starting screencast failed: %w", err)
+	}
+	return nil
+}
+
+// moveScreencast stops the screencast in the tab that lost focus and starts
+// it in the one that gained it.
+func (b *Browser) moveScreencast(from, to context.Context) {
+	b.mu.Lock()
+	on := b.onFrame != nil
+	b.mu.Unlock()
+	if !on || from == to {
+		return
+	}
+	if from != nil && from.Err() == nil {
+		chromedp.Run(from, page.StopScreencast())
+	}
+	if err := b.startScreencast(to); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+}
+
+// onScreencastFrame hands a frame of the active tab to the hook. Chrome sends
+// the next frame only once this one is acknowledged, so delaying the ack
+// caps the frame rate.
+func (b *Browser) onScreencastFrame(ctx context.Context, ev *page.EventScreencastFrame) {
+	b.mu.Lock()
+	hook := b.onFrame
+	interval := time.Second
+	if b.screencast.FPS > 0 {
+		interval = time.Second / time.Duration(b.screencast.FPS)
+	}
+	active := chromedp.FromContext(ctx).Target.TargetID == b.active
+	b.mu.Unlock()
+
+	if hook != nil && active {
+		f := Frame{Data: ev.Data, Time: time.Now()}
+		if m := ev.Metadata; m != nil {
+			f.Width, f.Height = int(m.DeviceWidth), int(m.DeviceHeight)
+			if m.Timestamp != nil {
+				f.Time = m.Timestamp.Time()
+			}
+		}
+		hook(f)
+	}
+
+	// Handlers must not issue commands synchronously
+	go func() {
+		time.Sleep(interval)
+		if err := chromedp.Run(ctx, page.ScreencastFrameAck(ev.SessionID)); err != nil && ctx.Err() == nil {
+			log.Printf("WARNING: could not acknowledge screencast frame: %v", err)
+		}
+	}()
+}
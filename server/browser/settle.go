@@ -0,0 +1,170 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// SettleOptions tune when a page counts as settled after an action.
+type SettleOptions struct {
+	NetworkIdle time.Duration // no requests in flight for this long
+	DOMQuiet    time.Duration // no DOM mutations for this long
+	Max         time.Duration // give up waiting after this long
+}
+
+// DefaultSettleOptions suit typical sites.
+var DefaultSettleOptions = SettleOptions{
+	NetworkIdle: 500 * time.Millisecond,
+	DOMQuiet:    300 * time.Millisecond,
+	Max:         10 * time.Second,
+}
+
+const (
+	settlePoll = 100 * time.Millisecond
+	// Requests open longer than this (long polling, streams) don't hold up settling
+	longRequest = 5 * time.Second
+)
+
+// domQuietScript installs a MutationObserver on first use and returns the
+// milliseconds since the document last changed.
+const domQuietScript = `(() => {
+	if (!window.__zenactMutations) {
+		window.__zenactMutations = { last: performance.now() };
+		new MutationObserver(() => { window.__zenactMutations.last = performance.now(); })
+			.observe(document, { subtree: true, childList: true, attributes: true, characterData: true });
+	}
+	return performance.now() - window.__zenactMutations.last;
+})()`
+
+// tabLoad is the load and network state of a tab, updated from its events.
+type tabLoad struct {
+	loading      bool
+	inflight     map[network.RequestID]time.Time
+	lastActivity time.Time
+}
+
+// SetSettleOptions replaces the settle thresholds; zero fields keep their defaults.
+func (b *Browser) SetSettleOptions(opts SettleOptions) {
+	if opts.NetworkIdle <= 0 {
+		opts.NetworkIdle = DefaultSettleOptions.NetworkIdle
+	}
+	if opts.DOMQuiet <= 0 {
+		opts.DOMQuiet = DefaultSettleOptions.DOMQuiet
+	}
+	if opts.Max <= 0 {
+		opts.Max = DefaultSettleOptions.Max
+	}
+	b.settle = opts
+}
+
+// loadState returns the load state of the tab a listener context belongs to.
+// b.mu must be held.
+func (b *Browser) loadState(ctx context.Context) *tabLoad {
+	t := b.findTab(chromedp.FromContext(ctx).Target.TargetID)
+	if t == nil {
+		return nil
+	}
+	if t.load.inflight == nil {
+		t.load.inflight = make(map[network.RequestID]time.Time)
+	}
+	return &t.load
+}
+
+func (b *Browser) onRequestStarted(ctx context.Context, id network.RequestID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l := b.loadState(ctx); l != nil {
+		if _, ok := l.inflight[id]; !ok {
+			l.inflight[id] = time.Now()
+		}
+		l.lastActivity = time.Now()
+	}
+}
+
+func (b *Browser) onRequestDone(ctx context.Context, id network.RequestID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l := b.loadState(ctx); l != nil {
+		delete(l.inflight, id)
+		l.lastActivity = time.Now()
+	}
+}
+
+// onFrameLoading tracks whether the tab's main frame is between starting and
+// finishing a load.
+func (b *Browser) onFrameLoading(ctx context.Context, frame cdp.FrameID, loading bool) {
+	c := chromedp.FromContext(ctx)
+	if frame != cdp.FrameID(c.Target.TargetID) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l := b.loadState(ctx); l != nil {
+		l.loading = loading
+		l.lastActivity = time.Now()
+	}
+}
+
+// networkIdle reports whether the active tab has finished loading and had no
+// requests in flight for at least d, counting from since at the earliest.
+func (b *Browser) networkIdle(d time.Duration, since time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.findTab(b.active)
+	if t == nil {
+		return true
+	}
+	if t.load.loading {
+		return false
+	}
+	now := time.Now()
+	for _, started := range t.load.inflight {
+		if now.Sub(started) < longRequest {
+			return false
+		}
+	}
+	last := t.load.lastActivity
+	if last.Before(since) {
+		last = since
+	}
+	return now.Sub(last) >= d
+}
+
+// domQuiet reports whether the active tab's DOM has not changed for at least d.
+func (b *Browser) domQuiet(d time.Duration) bool {
+	ctx, cancel := context.WithTimeout(b.activeCtx(), time.Second)
+	defer cancel()
+	var sinceMs float64
+	if err := chromedp.Run(ctx, chromedp.Evaluate(domQuietScript, &sinceMs)); err != nil {
+		return false
+	}
+	return time.Duration(sinceMs*float64(time.Millisecond)) >= d
+}
+
+// WaitSettled waits until the active tab has loaded, the network is idle and
+// the DOM has stopped changing, or until the configured cap. It returns false
+// if the page was still busy at the cap. A dialog waiting for the agent ends
+// the wait, since the page can't progress until it is handled.
+func (b *Browser) WaitSettled() bool {
+	opts := b.settle
+	// Loads an action triggers start a moment after it returns, so the page
+	// must stay quiet for a full window after the wait begins
+	start := time.Now()
+	deadline := start.Add(opts.Max)
+	for {
+		if b.PendingDialog() != nil {
+			return true
+		}
+		if b.networkIdle(opts.NetworkIdle, start) && b.domQuiet(opts.DOMQuiet) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(settlePoll)
+	}
+}
@@ -0,0 +1,384 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// Tab is an open page as reported to the agent. Tabs are numbered from 1 in
+// the order they were opened.
+type Tab struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+type tab struct {
+	id     target.ID
+	opener target.ID
+	title  string
+	url    string
+	closed bool
+	load   tabLoad
+
+	// ctx is attached when a popup opens, or else lazily the first time the
+	// tab is switched to. ready is set while an attach is in progress and
+	// closed when it ends.
+	ctx    context.Context
+	cancel context.CancelFunc
+	ready  chan struct{}
+}
+
+// onTargetCreated starts tracking a new page. Pages opened by another page
+// (target=_blank links, window.open popups) are remembered so FollowTabs can
+// switch to them.
+func (b *Browser) onTargetCreated(info *target.Info) {
+	if info.Type != "page" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.trackTab(info.TargetID)
+	t.title, t.url, t.opener = info.Title, info.URL, info.OpenerID
+	if info.OpenerID != "" {
+		b.popups = append(b.popups, info.TargetID)
+	}
+}
+
+// onAutoAttached handles a target Chrome paused before its first navigation.
+// Popups are attached and prepared first, so interception, headers and
+// emulation apply from their first request; other targets resume at once.
+// Dropping the paused session is what lets the target run.
+func (b *Browser) onAutoAttached(e *target.EventAttachedToTarget) {
+	info := e.TargetInfo
+	var popup *tab
+	if info.Type == "page" && info.OpenerID != "" {
+		b.mu.Lock()
+		popup = b.trackTab(info.TargetID)
+		popup.opener = info.OpenerID
+		b.mu.Unlock()
+	}
+
+	go func() {
+		browserCtx := cdp.WithExecutor(b.rootCtx, chromedp.FromContext(b.rootCtx).Browser)
+		defer target.DetachFromTarget().WithSessionID(e.SessionID).Do(browserCtx)
+		if popup == nil {
+			return
+		}
+		if _, err := b.attach(popup); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}()
+}
+
+// attach returns the tab's context, attaching to the tab and preparing it
+// first unless that already happened. Callers racing to attach the same tab
+// wait for the first, so each tab is attached once.
+func (b *Browser) attach(t *tab) (context.Context, error) {
+	b.mu.Lock()
+	for t.ctx == nil && t.ready != nil {
+		ready := t.ready
+		b.mu.Unlock()
+		<-ready
+		b.mu.Lock()
+	}
+	if t.ctx != nil {
+		ctx := t.ctx
+		b.mu.Unlock()
+		return ctx, nil
+	}
+	ready := make(chan struct{})
+	t.ready = ready
+	b.mu.Unlock()
+	defer close(ready)
+
+	ctx, cancel := chromedp.NewContext(b.rootCtx, chromedp.WithTargetID(t.id))
+	b.listen(ctx)
+	err := chromedp.Run(ctx)
+	if err == nil {
+		b.prepareTab(ctx)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t.ready = nil
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("attaching to tab failed: %w", err)
+	}
+	t.ctx, t.cancel = ctx, cancel
+	return ctx, nil
+}
+
+func (b *Browser) onTargetInfoChanged(info *target.Info) {
+	if info.Type != "page" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t := b.findTab(info.TargetID); t != nil {
+		t.title, t.url = info.Title, info.URL
+	}
+}
+
+// onTargetDestroyed marks a tab closed. The active tab is only replaced by
+// FollowTabs, on the agent's goroutine, so b.ctx never changes under an action.
+func (b *Browser) onTargetDestroyed(id target.ID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t := b.findTab(id); t != nil {
+		t.closed = true
+	}
+}
+
+// trackTab returns the entry for a target, adding it if needed. b.mu must be held.
+func (b *Browser) trackTab(id target.ID) *tab {
+	if t := b.findTab(id); t != nil {
+		return t
+	}
+	t := &tab{id: id}
+	b.tabs = append(b.tabs, t)
+	return t
+}
+
+// findTab looks up an open tab. b.mu must be held.
+func (b *Browser) findTab(id target.ID) *tab {
+	for _, t := range b.tabs {
+		if t.id == id && !t.closed {
+			return t
+		}
+	}
+	return nil
+}
+
+// openTabs drops closed tabs and returns the remaining ones. b.mu must be held.
+func (b *Browser) openTabs() []*tab {
+	open := b.tabs[:0]
+	for _, t := range b.tabs {
+		if !t.closed {
+			open = append(open, t)
+		} else if t.cancel != nil {
+			t.cancel()
+		}
+	}
+	b.tabs = open
+	return open
+}
+
+// Tabs lists the open tabs.
+func (b *Browser) Tabs() []Tab {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var tabs []Tab
+	for i, t := range b.openTabs() {
+		tabs = append(tabs, Tab{
+			Number: i + 1,
+			Title:  t.title,
+			URL:    t.url,
+			Active: t.id == b.active,
+		})
+	}
+	return tabs
+}
+
+// FollowTabs keeps the active tab in step with the page: when the active tab
+// opened a popup since the last call it becomes active, and when the active
+// tab was closed (e.g. an OAuth popup closing itself) its opener, or else the
+// most recent tab, takes over. It returns a note describing the switch, or ""
+// if the active tab did not change.
+func (b *Browser) FollowTabs() (string, error) {
+	b.mu.Lock()
+	open := b.openTabs()
+	var next *tab
+	reason := ""
+	for _, id := range b.popups {
+		if t := b.findTab(id); t != nil && t.opener == b.active {
+			next = t
+			reason = "the last action opened a new tab"
+		}
+	}
+	b.popups = nil
+	if next == nil && b.findTab(b.active) == nil && len(open) > 0 {
+		next = open[len(open)-1]
+		for _, t := range open {
+			if t.id == b.activeOpener {
+				next = t
+			}
+		}
+		reason = "the previous tab was closed"
+	}
+	var note string
+	if next != nil {
+		note = fmt.Sprintf("Switched to tab %q (%s) because %s", next.title, next.url, reason)
+	}
+	b.mu.Unlock()
+
+	if next == nil {
+		return "", nil
+	}
+	if err := b.activate(next); err != nil {
+		return "", err
+	}
+	return note, nil
+}
+
+// SwitchTab makes the tab with the given number active.
+func (b *Browser) SwitchTab(number int) error {
+	t, err := b.tabByNumber(number)
+	if err != nil {
+		return err
+	}
+	return b.activate(t)
+}
+
+// NewTab opens a tab, navigates it to url unless it is empty and makes it active.
+func (b *Browser) NewTab(url string) error {
+	if url == "" {
+		url = "about:blank"
+	}
+	ctx, cancel := chromedp.NewContext(b.rootCtx)
+	b.listen(ctx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return fmt.Errorf("opening tab failed: %w", err)
+	}
+	b.prepareTab(ctx)
+	id := chromedp.FromContext(ctx).Target.TargetID
+
+	b.mu.Lock()
+	t := b.trackTab(id)
+	t.ctx, t.cancel = ctx, cancel
+	b.mu.Unlock()
+
+	if err := b.activate(t); err != nil {
+		return err
+	}
+	if url != "about:blank" {
+		if err := b.Navigate(url); err != nil {
+			return fmt.Errorf("navigate to %s failed: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// CloseTab closes the tab with the given number, or the active tab if number
+// is 0. Closing the active tab activates its opener or the most recent tab.
+func (b *Browser) CloseTab(number int) error {
+	b.mu.Lock()
+	count := len(b.openTabs())
+	b.mu.Unlock()
+	if count <= 1 {
+		return fmt.Errorf("cannot close the only open tab")
+	}
+
+	var t *tab
+	if number == 0 {
+		b.mu.Lock()
+		t = b.findTab(b.active)
+		b.mu.Unlock()
+	} else {
+		var err error
+		if t, err = b.tabByNumber(number); err != nil {
+			return err
+		}
+	}
+	if t == nil {
+		return fmt.Errorf("no active tab")
+	}
+
+	browserCtx := cdp.WithExecutor(b.rootCtx, chromedp.FromContext(b.rootCtx).Browser)
+	if err := target.CloseTarget(t.id).Do(browserCtx); err != nil {
+		return fmt.Errorf("closing tab failed: %w", err)
+	}
+
+	b.mu.Lock()
+	t.closed = true
+	b.mu.Unlock()
+	_, err := b.FollowTabs()
+	return err
+}
+
+func (b *Browser) tabByNumber(number int) (*tab, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	open := b.openTabs()
+	if number < 1 || number > len(open) {
+		return nil, fmt.Errorf("no tab %d; %d tabs are open", number, len(open))
+	}
+	return open[number-1], nil
+}
+
+// activate attaches to a tab if needed, brings it to the front and directs
+// all further actions at it.
+func (b *Browser) activate(t *tab) error {
+	ctx, err := b.attach(t)
+	if err != nil {
+		return err
+	}
+	if err := chromedp.Run(ctx, page.BringToFront()); err != nil {
+		return fmt.Errorf("switching tab failed: %w", err)
+	}
+
+	b.mu.Lock()
+	prev := b.ctx
+	b.active = t.id
+	b.activeOpener = t.opener
+	b.ctx = ctx
+	b.marks = nil
+	b.frame = frameGeometry{}
+	b.worlds = nil
+	b.mu.Unlock()
+
+	b.moveScreencast(prev, ctx)
+	return nil
+}
+
+// prepareTab applies per-session browser settings to a newly attached tab.
+func (b *Browser) prepareTab(ctx context.Context) {
+	b.mu.Lock()
+	dir := b.downloadDir
+	intercept := b.intercepting()
+	b.mu.Unlock()
+	if dir != "" {
+		chromedp.Run(ctx, cdpbrowser.SetDownloadBehavior(cdpbrowser.SetDownloadBehaviorBehaviorAllowAndName).
+			WithDownloadPath(dir).
+			WithEventsEnabled(true))
+	}
+	if intercept {
+		if err := b.enableInterception(ctx); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+	if err := b.applyHeaders(ctx); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+	if err := b.applyEmulation(ctx); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+}
+
+// DescribeTabs renders the open tabs for the prompt.
+func DescribeTabs(tabs []Tab) string {
+	var sb strings.Builder
+	for _, t := range tabs {
+		title := t.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(&sb, "[%d] %s — %s", t.Number, title, t.URL)
+		if t.Active {
+			sb.WriteString(" (active)")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
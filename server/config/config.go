@@ -1,10 +1,15 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/anamika/zenact-web/server/browser"
 	"github.com/joho/godotenv"
 )
 
@@ -16,6 +21,86 @@ type Config struct {
 	BrowserHeight    int
 	MaxIterations    int
 	ServerPort       string
+
+	// Keep cross-origin iframes in the page's process so snapshots and
+	// actions reach into them, giving up the protection site isolation offers
+	// against the pages visited
+	DisableSiteIsolation bool
+
+	// Per-task working directories (input files, downloads, artifacts)
+	TaskDataDir string
+	// Root that tasks may reference input files from; empty disables references
+	InputFilesDir string
+	// Size limit of each uploaded file and of all uploads of a request
+	MaxUploadMB int
+
+	// What to do with alert/confirm/prompt dialogs: ask (the agent decides),
+	// accept or dismiss
+	DialogPolicy string
+
+	// Page settle detection after each action
+	SettleNetworkIdle time.Duration
+	SettleDOMQuiet    time.Duration
+	SettleMax         time.Duration
+
+	// Hosts pages may load from; see browser.DomainPolicy for the pattern syntax
+	AllowedDomains []string
+	DeniedDomains  []string
+
+	// Resource types every task drops (image, media, font, ...) and a file of
+	// ad/tracker hosts to block, in hosts or Adblock host-rule format
+	BlockResourceTypes []string
+	BlocklistFile      string
+
+	// Network identity: an optional proxy (credentials in the URL), headers
+	// sent with every request (a JSON object) and credentials answering HTTP
+	// auth challenges, optionally only from BasicAuthOrigin
+	ProxyURL          string
+	ProxyBypass       []string
+	ExtraHTTPHeaders  map[string]string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	BasicAuthOrigin   string
+
+	// Emulation defaults: device preset, locale, IANA timezone and a
+	// position given as "latitude,longitude[,accuracy]"
+	Device      string
+	Locale      string
+	Timezone    string
+	Geolocation []float64
+
+	// Live view of the active tab: at most ScreencastFPS JPEG frames per
+	// second (0 disables it) at ScreencastQuality
+	ScreencastFPS     int
+	ScreencastQuality int
+
+	// Screenshots sent to the model are scaled down to fit ScreenshotMaxWidth
+	// x ScreenshotMaxHeight, encoded as png or jpeg (at ScreenshotQuality) and
+	// requested at ScreenshotDetail (high, low or auto)
+	ScreenshotMaxWidth  int
+	ScreenshotMaxHeight int
+	ScreenshotFormat    string
+	ScreenshotQuality   int
+	ScreenshotDetail    string
+
+	// Approximate tokens of distilled page structure sent to the model
+	DOMTokenBudget int
+
+	// Failures of the same selector on one page before the model is barred
+	// from using it there; 0 disables automatic blocking
+	SelectorBlockAfter int
+
+	// Every SummaryInterval steps the model rewrites the task memory from the
+	// step log, keeping it under SummaryTokenBudget tokens; 0 turns it off
+	SummaryInterval    int
+	SummaryTokenBudget int
+
+	// Network recording, exported per task as HAR. Bodies are kept only when
+	// enabled, at most NetworkBodyMaxKB each and for the listed MIME prefixes
+	NetworkCapture       bool
+	NetworkCaptureBodies bool
+	NetworkBodyMaxKB     int
+	NetworkBodyMIMETypes []string
 }
 
 func Load() (*Config, error) {
@@ -29,11 +114,116 @@ func Load() (*Config, error) {
 		BrowserHeight:    getEnvInt("BROWSER_HEIGHT", 900),
 		MaxIterations:    getEnvInt("MAX_ITERATIONS", 30),
 		ServerPort:       getEnvOrDefault("SERVER_PORT", "8080"),
+		TaskDataDir:      getEnvOrDefault("TASK_DATA_DIR", filepath.Join(os.TempDir(), "zenact-tasks")),
+		InputFilesDir:    os.Getenv("INPUT_FILES_DIR"),
+		MaxUploadMB:      getEnvInt("MAX_UPLOAD_MB", 25),
+		DialogPolicy:     getEnvOrDefault("DIALOG_POLICY", "ask"),
+
+		DisableSiteIsolation: getEnvOrDefault("BROWSER_DISABLE_SITE_ISOLATION", "false") == "true",
+
+		SettleNetworkIdle: time.Duration(getEnvInt("SETTLE_NETWORK_IDLE_MS", 500)) * time.Millisecond,
+		SettleDOMQuiet:    time.Duration(getEnvInt("SETTLE_DOM_QUIET_MS", 300)) * time.Millisecond,
+		SettleMax:         time.Duration(getEnvInt("SETTLE_MAX_MS", 10000)) * time.Millisecond,
+
+		AllowedDomains: getEnvList("ALLOWED_DOMAINS", ""),
+		DeniedDomains:  getEnvList("DENIED_DOMAINS", ""),
+
+		BlockResourceTypes: getEnvList("BLOCK_RESOURCE_TYPES", ""),
+		BlocklistFile:      os.Getenv("BLOCKLIST_FILE"),
+
+		ProxyURL:          os.Getenv("PROXY_URL"),
+		ProxyBypass:       getEnvList("PROXY_BYPASS", ""),
+		BasicAuthUser:     os.Getenv("BASIC_AUTH_USER"),
+		BasicAuthPassword: os.Getenv("BASIC_AUTH_PASSWORD"),
+		BasicAuthOrigin:   os.Getenv("BASIC_AUTH_ORIGIN"),
+
+		Device:   os.Getenv("DEVICE"),
+		Locale:   os.Getenv("LOCALE"),
+		Timezone: os.Getenv("TIMEZONE"),
+
+		ScreencastFPS:     getEnvInt("SCREENCAST_FPS", 5),
+		ScreencastQuality: getEnvInt("SCREENCAST_QUALITY", 60),
+
+		ScreenshotMaxWidth:  getEnvInt("SCREENSHOT_MAX_WIDTH", 1280),
+		ScreenshotMaxHeight: getEnvInt("SCREENSHOT_MAX_HEIGHT", 1280),
+		ScreenshotFormat:    getEnvOrDefault("SCREENSHOT_FORMAT", "jpeg"),
+		ScreenshotQuality:   getEnvInt("SCREENSHOT_QUALITY", 80),
+		ScreenshotDetail:    getEnvOrDefault("SCREENSHOT_DETAIL", "high"),
+
+		DOMTokenBudget:     getEnvInt("DOM_TOKEN_BUDGET", 2500),
+		SelectorBlockAfter: getEnvInt("SELECTOR_BLOCK_AFTER", 2),
+		SummaryInterval:    getEnvInt("SUMMARY_INTERVAL", 5),
+		SummaryTokenBudget: getEnvInt("SUMMARY_TOKEN_BUDGET", 600),
+
+		NetworkCapture:       getEnvOrDefault("NETWORK_CAPTURE", "true") == "true",
+		NetworkCaptureBodies: getEnvOrDefault("NETWORK_CAPTURE_BODIES", "false") == "true",
+		NetworkBodyMaxKB:     getEnvInt("NETWORK_BODY_MAX_KB", 256),
+		NetworkBodyMIMETypes: getEnvList("NETWORK_BODY_MIME_TYPES", "text/,application/json,application/javascript,application/xml"),
 	}
 
 	if cfg.OpenRouterAPIKey == "" {
 		return nil, fmt.Errorf("OPENROUTER_API_KEY is required")
 	}
+	switch cfg.DialogPolicy {
+	case "ask", "accept", "dismiss":
+	default:
+		return nil, fmt.Errorf("DIALOG_POLICY must be ask, accept or dismiss, got %q", cfg.DialogPolicy)
+	}
+	if err := browser.ValidateDomainPatterns(append(cfg.AllowedDomains, cfg.DeniedDomains...)); err != nil {
+		return nil, fmt.Errorf("ALLOWED_DOMAINS or DENIED_DOMAINS: %w", err)
+	}
+	if raw := os.Getenv("EXTRA_HTTP_HEADERS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.ExtraHTTPHeaders); err != nil {
+			return nil, fmt.Errorf("EXTRA_HTTP_HEADERS must be a JSON object of strings: %w", err)
+		}
+	}
+	if raw := os.Getenv("GEOLOCATION"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("GEOLOCATION must be latitude,longitude[,accuracy], got %q", raw)
+			}
+			cfg.Geolocation = append(cfg.Geolocation, v)
+		}
+		if n := len(cfg.Geolocation); n < 2 || n > 3 {
+			return nil, fmt.Errorf("GEOLOCATION must be latitude,longitude[,accuracy], got %q", raw)
+		}
+	}
+	if cfg.ScreencastFPS < 0 || cfg.ScreencastFPS > 30 {
+		return nil, fmt.Errorf("SCREENCAST_FPS must be between 0 and 30, got %d", cfg.ScreencastFPS)
+	}
+	if cfg.ScreencastQuality < 1 || cfg.ScreencastQuality > 100 {
+		return nil, fmt.Errorf("SCREENCAST_QUALITY must be between 1 and 100, got %d", cfg.ScreencastQuality)
+	}
+	if cfg.ScreenshotMaxWidth < 200 || cfg.ScreenshotMaxHeight < 200 {
+		return nil, fmt.Errorf("SCREENSHOT_MAX_WIDTH and SCREENSHOT_MAX_HEIGHT must be at least 200")
+	}
+	if cfg.ScreenshotFormat != "png" && cfg.ScreenshotFormat != "jpeg" {
+		return nil, fmt.Errorf("SCREENSHOT_FORMAT must be png or jpeg, got %q", cfg.ScreenshotFormat)
+	}
+	if cfg.ScreenshotQuality < 1 || cfg.ScreenshotQuality > 100 {
+		return nil, fmt.Errorf("SCREENSHOT_QUALITY must be between 1 and 100, got %d", cfg.ScreenshotQuality)
+	}
+	switch cfg.ScreenshotDetail {
+	case "high", "low", "auto":
+	default:
+		return nil, fmt.Errorf("SCREENSHOT_DETAIL must be high, low or auto, got %q", cfg.ScreenshotDetail)
+	}
+	if cfg.DOMTokenBudget < 200 {
+		return nil, fmt.Errorf("DOM_TOKEN_BUDGET must be at least 200, got %d", cfg.DOMTokenBudget)
+	}
+	if cfg.SelectorBlockAfter < 0 {
+		return nil, fmt.Errorf("SELECTOR_BLOCK_AFTER must not be negative, got %d", cfg.SelectorBlockAfter)
+	}
+	if cfg.SummaryInterval < 0 {
+		return nil, fmt.Errorf("SUMMARY_INTERVAL must not be negative, got %d", cfg.SummaryInterval)
+	}
+	if cfg.SummaryTokenBudget < 100 {
+		return nil, fmt.Errorf("SUMMARY_TOKEN_BUDGET must be at least 100, got %d", cfg.SummaryTokenBudget)
+	}
+	if err := browser.ValidateResourceTypes(cfg.BlockResourceTypes); err != nil {
+		return nil, fmt.Errorf("BLOCK_RESOURCE_TYPES: %w", err)
+	}
 	return cfg, nil
 }
 
@@ -55,3 +245,14 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return n
 }
+
+// getEnvList splits a comma-separated variable, dropping empty items.
+func getEnvList(key, fallback string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnvOrDefault(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -73,81 +73,144 @@ type chatResponse struct {
 	} `json:"error"`
 }
 
+// Observation is the page state and task context the model decides on.
+type Observation struct {
+	TaskPrompt       string
+	Screenshot       []byte
+	ScreenshotMIME   string // image/png when empty
+	ScreenshotDetail string // high when empty
+	PageURL          string
+	PageTitle        string
+	History          []models.Step
+	DOM              string // distilled and already within its token budget
+	AXTree           string
+	Elements         string
+	Tabs             string
+	TabNote          string // set when the active tab changed on its own
+	Dialog           string
+	Changes          string   // what the last action changed on the page
+	ConsoleErrors    []string // page errors during the last action
+	Policy           string   // domain restrictions and what they blocked
+	Files            []string
+	Downloads        []string
+	Summary          string // already within its token budget
+	BlockedSelectors []string
+}
+
 // Decide sends the current browser state to the vision LLM and returns a structured action.
-func (c *Client) Decide(
-	ctx context.Context,
-	systemPrompt string,
-	screenshot []byte,
-	pageURL string,
-	pageTitle string,
-	taskPrompt string,
-	history []models.Step,
-	domContent string,
-	axTree string,
-	summary string,
-	blockedSelectors []string,
-) (*models.LLMResponse, error) {
+func (c *Client) Decide(ctx context.Context, systemPrompt string, obs Observation) (*models.LLMResponse, error) {
 	// System message
 	sysMsg := chatMessage{Role: "system", Content: systemPrompt}
 
 	// Build history summary
-	historyText := buildHistoryText(history)
+	historyText := buildHistoryText(obs.History)
 
 	// User message with screenshot + context
-	b64Screenshot := base64.StdEncoding.EncodeToString(screenshot)
+	b64Screenshot := base64.StdEncoding.EncodeToString(obs.Screenshot)
+
+	elementsContext := ""
+	if obs.Elements != "" {
+		elementsContext = fmt.Sprintf("\n\n## INTERACTIVE ELEMENTS (numbered in the screenshot)\n%s", truncate(obs.Elements, 6000))
+	}
 
 	domContext := ""
-	if domContent != "" {
-		domContext = fmt.Sprintf("\n\n## FULL DOM STRUCTURE\n%s", truncate(domContent, 8000))
+	if obs.DOM != "" {
+		domContext = fmt.Sprintf("\n\n## PAGE STRUCTURE (most relevant to the task)\n%s", obs.DOM)
 	}
 
 	axContext := ""
-	if axTree != "" {
-		axContext = fmt.Sprintf("\n\n## ACCESSIBILITY TREE\n%s", truncate(axTree, 4000))
+	if obs.AXTree != "" {
+		axContext = fmt.Sprintf("\n\n## ACCESSIBILITY TREE\n%s", truncate(obs.AXTree, 4000))
+	}
+
+	changesContext := ""
+	if obs.Changes != "" {
+		changesContext = fmt.Sprintf("\n\n## WHAT CHANGED (since your last action)\n%s", obs.Changes)
+	}
+
+	dialogContext := ""
+	if obs.Dialog != "" {
+		dialogContext = fmt.Sprintf("\n\n## JAVASCRIPT DIALOG\n%s", obs.Dialog)
+	}
+
+	consoleContext := ""
+	if len(obs.ConsoleErrors) > 0 {
+		consoleContext = fmt.Sprintf("\n\n## PAGE ERRORS (during your last action)\n%s", truncate(strings.Join(obs.ConsoleErrors, "\n"), 2000))
+	}
+
+	policyContext := ""
+	if obs.Policy != "" {
+		policyContext = fmt.Sprintf("\n\n## DOMAIN RESTRICTIONS\n%s", obs.Policy)
 	}
 
 	summaryContext := ""
-	if summary != "" {
-		summaryContext = fmt.Sprintf("\n\n## TASK SUMMARY (Long-term Memory)\n%s", truncate(summary, 3000))
+	if obs.Summary != "" {
+		summaryContext = fmt.Sprintf("\n\n## TASK SUMMARY (Long-term Memory)\n%s", obs.Summary)
+	}
+
+	filesContext := ""
+	if len(obs.Files) > 0 {
+		filesContext = fmt.Sprintf("\n\n## FILES AVAILABLE FOR UPLOAD\n%s", strings.Join(obs.Files, "\n"))
+	}
+
+	if len(obs.Downloads) > 0 {
+		filesContext += fmt.Sprintf("\n\n## DOWNLOADED FILES\n%s", strings.Join(obs.Downloads, "\n"))
+	}
+
+	tabsContext := ""
+	if obs.Tabs != "" {
+		tabsContext = fmt.Sprintf("\n\n## OPEN TABS\n%s", obs.Tabs)
+		if obs.TabNote != "" {
+			tabsContext += fmt.Sprintf("NOTE: %s", obs.TabNote)
+		}
 	}
 
 	blockedContext := ""
-	if len(blockedSelectors) > 0 {
-		blockedContext = fmt.Sprintf("\n\n## BLOCKED SELECTORS (DO NOT USE)\n%s\nThese selectors have FAILED. Do NOT use them again.", strings.Join(blockedSelectors, "\n"))
+	if len(obs.BlockedSelectors) > 0 {
+		blockedContext = fmt.Sprintf("\n\n## BLOCKED SELECTORS (DO NOT USE)\n%s\nThese selectors failed repeatedly on this page or were blocked by the user. Do NOT use them; find the element another way.", strings.Join(obs.BlockedSelectors, "\n"))
 	}
 
 	userContent := []contentPart{
 		{
 			Type: "text",
 			Text: fmt.Sprintf(
-				"Task: %s\n\nCurrent URL: %s\nPage Title: %s%s%s%s%s\n\nPrevious actions (last 5):\n%s\n\nCRITICAL:\n1. Use the DOM STRUCTURE and ACCESSIBILITY TREE to find elements\n2. Prefer selectors: #id > [name=...] > [aria-label=...] > .class\n3. DO NOT use blocked selectors\n4. Verify selector matches visible element before returning\nRespond with JSON only.",
-				taskPrompt, pageURL, pageTitle, summaryContext, blockedContext, domContext, axContext, historyText,
+				"Task: %s\n\nCurrent URL: %s\nPage Title: %s%s%s%s%s%s%s%s%s%s%s%s\n\nPrevious actions (last 5):\n%s\n\nCRITICAL:\n1. Target elements by their number from INTERACTIVE ELEMENTS whenever possible\n2. Fall back to a CSS selector from PAGE STRUCTURE only if the element is not numbered: #id > [name=...] > [aria-label=...] > .class\n3. DO NOT use blocked selectors\n4. Verify the element is visible before returning\nRespond with JSON only.",
+				obs.TaskPrompt, obs.PageURL, obs.PageTitle, changesContext, dialogContext, tabsContext, policyContext, consoleContext, summaryContext, filesContext, blockedContext, elementsContext, domContext, axContext, historyText,
 			),
 		},
 		{
 			Type: "image_url",
 			ImageURL: &imageURL{
-				URL:    fmt.Sprintf("data:image/png;base64,%s", b64Screenshot),
-				Detail: "high",
+				URL:    fmt.Sprintf("data:%s;base64,%s", orDefault(obs.ScreenshotMIME, "image/png"), b64Screenshot),
+				Detail: orDefault(obs.ScreenshotDetail, "high"),
 			},
 		},
 	}
 
 	userMsg := chatMessage{Role: "user", Content: userContent}
 
+	content, err := c.complete(ctx, []chatMessage{sysMsg, userMsg})
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONFromContent(content)
+}
+
+// complete sends a chat request and returns the first choice's content.
+func (c *Client) complete(ctx context.Context, messages []chatMessage) (string, error) {
 	reqBody := chatRequest{
 		Model:    c.model,
-		Messages: []chatMessage{sysMsg, userMsg},
+		Messages: messages,
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", openRouterURL, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -156,17 +219,17 @@ func (c *Client) Decide(
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &APIError{
+		return "", &APIError{
 			StatusCode: resp.StatusCode,
 			Body:       string(respBody),
 		}
@@ -174,23 +237,22 @@ func (c *Client) Decide(
 
 	var chatResp chatResponse
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if chatResp.Error != nil {
-		return nil, fmt.Errorf("OpenRouter error: %s", chatResp.Error.Message)
+		return "", fmt.Errorf("OpenRouter error: %s", chatResp.Error.Message)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
+		return "", fmt.Errorf("no choices in response")
 	}
 
-	content := chatResp.Choices[0].Message.Content
-	return parseJSONFromContent(content, blockedSelectors)
+	return chatResp.Choices[0].Message.Content, nil
 }
 
 // parseJSONFromContent extracts JSON from LLM content, handling markdown code fences.
-func parseJSONFromContent(content string, blockedSelectors []string) (*models.LLMResponse, error) {
+func parseJSONFromContent(content string) (*models.LLMResponse, error) {
 	content = strings.TrimSpace(content)
 
 	// Strip markdown code fences if present
@@ -209,12 +271,6 @@ func parseJSONFromContent(content string, blockedSelectors []string) (*models.LL
 		return nil, fmt.Errorf("failed to parse LLM JSON: %w\nraw content: %s", err, content)
 	}
 
-	for _, blocked := range blockedSelectors {
-		if resp.Selector == blocked {
-			return nil, fmt.Errorf("LLM used blocked selector '%s' - choose different selector", resp.Selector)
-		}
-	}
-
 	return &resp, nil
 }
 
@@ -225,6 +281,13 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
 // buildHistoryText creates a concise summary of previous steps.
 func buildHistoryText(history []models.Step) string {
 	if len(history) == 0 {
@@ -248,6 +311,15 @@ func buildHistoryText(history []models.Step) string {
 		// Build step line
 		fmt.Fprintf(&sb, "Step %d [%s]: %s", step.Iteration, status, step.Action.Type)
 
+		if step.Action.Element > 0 {
+			fmt.Fprintf(&sb, " on element=%d", step.Action.Element)
+		}
+		if step.Action.X != 0 || step.Action.Y != 0 {
+			fmt.Fprintf(&sb, " at x=%.0f y=%.0f", step.Action.X, step.Action.Y)
+		}
+		if step.Action.Type == models.ActionDragTo {
+			fmt.Fprintf(&sb, " to x=%.0f y=%.0f", step.Action.ToX, step.Action.ToY)
+		}
 		if step.Action.Selector != "" {
 			fmt.Fprintf(&sb, " on selector=%q", step.Action.Selector)
 		}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const summaryPrompt = `You maintain the long-term memory of a browser automation agent. You receive the task, the current memory and log entries of the steps taken since it was written. Rewrite the memory so it reflects everything the agent has learned, using exactly these sections:
+
+GOAL: the task restated in one or two lines, with any constraints
+COMPLETED: sub-goals already achieved, one per line
+FACTS: information discovered that later steps need (values, names, URLs, where things are on the site), one per line
+FAILURES: approaches that did not work and why, so they are not repeated, one per line
+NEXT: the immediate next sub-goal
+
+Keep facts exact (numbers, spellings, URLs). Merge duplicates and drop details that no longer matter, but never drop a fact or failure that is still relevant. Stay under %d words. Reply with the memory only, no preamble or markdown fences.`
+
+// Summarize folds new step log entries into the agent's memory of a task and
+// returns the rewritten memory, aiming for at most maxTokens tokens.
+func (c *Client) Summarize(ctx context.Context, task, memory string, entries []string, maxTokens int) (string, error) {
+	if memory == "" {
+		memory = "(empty - the task has just started)"
+	}
+	user := fmt.Sprintf("Task: %s\n\nCurrent memory:\n%s\n\nNew log entries:\n%s", task, memory, strings.Join(entries, "\n"))
+
+	content, err := c.complete(ctx, []chatMessage{
+		{Role: "system", Content: fmt.Sprintf(summaryPrompt, maxTokens*3/4)},
+		{Role: "user", Content: user},
+	})
+	if err != nil {
+		return "", err
+	}
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return content, nil
+}
@@ -0,0 +1,341 @@
+// Package replay renders the screenshots of a task into an animated GIF or
+// an MJPEG video, with a caption per step and a marker where the step
+// clicked.
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+)
+
+// Frame is one step of the replay.
+type Frame struct {
+	Image   image.Image
+	Caption string       // lines separated by \n, wrapped to the frame width
+	Cursor  *image.Point // action target in Image pixels, if any
+}
+
+// Options control the size and pace of a replay.
+type Options struct {
+	Width int           // frames are scaled to this width; 0 keeps the original
+	Delay time.Duration // how long each frame is shown
+}
+
+const (
+	captionLines = 3
+	jpegQuality  = 80
+)
+
+var (
+	captionBackground = color.RGBA{20, 20, 20, 255}
+	captionText       = color.RGBA{240, 240, 240, 255}
+	cursorColor       = color.RGBA{230, 30, 30, 255}
+	cursorOutline     = color.RGBA{255, 255, 255, 255}
+)
+
+// WriteGIF encodes the frames as a looping animated GIF.
+func WriteGIF(w io.Writer, frames []Frame, opts Options) error {
+	canvases, err := render(frames, opts)
+	if err != nil {
+		return err
+	}
+	delay := int(opts.Delay / (10 * time.Millisecond))
+	anim := &gif.GIF{}
+	for i, c := range canvases {
+		anim.Image = append(anim.Image, quantize(c))
+		d := delay
+		if i == len(canvases)-1 {
+			d *= 2 // linger on the outcome
+		}
+		anim.Delay = append(anim.Delay, d)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// WriteMJPEG encodes the frames as Motion JPEG in an AVI container, which
+// common players and browsers' download handlers recognise.
+func WriteMJPEG(w io.Writer, frames []Frame, opts Options) error {
+	canvases, err := render(frames, opts)
+	if err != nil {
+		return err
+	}
+	// The last frame is repeated to linger on the outcome
+	canvases = append(canvases, canvases[len(canvases)-1])
+
+	var jpegs [][]byte
+	for _, c := range canvases {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, c, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return fmt.Errorf("encoding frame failed: %w", err)
+		}
+		jpegs = append(jpegs, buf.Bytes())
+	}
+	b := canvases[0].Bounds()
+	_, err = w.Write(avi(jpegs, b.Dx(), b.Dy(), opts.Delay))
+	return err
+}
+
+// render draws every frame onto a canvas of the same size: the screenshot
+// scaled to the requested width above a caption bar.
+func render(frames []Frame, opts Options) ([]*image.RGBA, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to render")
+	}
+	first := frames[0].Image.Bounds()
+	width := opts.Width
+	if width <= 0 || width > first.Dx() {
+		width = first.Dx()
+	}
+	height := first.Dy() * width / first.Dx()
+
+	scale := 2
+	if width < 600 {
+		scale = 1
+	}
+	pad := 4 * scale
+	lineH := (glyphH + 3) * scale
+	bar := 2*pad + captionLines*lineH
+
+	canvases := make([]*image.RGBA, len(frames))
+	for i, f := range frames {
+		c := image.NewRGBA(image.Rect(0, 0, width, height+bar))
+		draw.Draw(c, c.Bounds(), image.Black, image.Point{}, draw.Src)
+
+		// Frames taller than the first are cut off at the bottom
+		src := f.Image.Bounds()
+		h := src.Dy() * width / src.Dx()
+		visible := src
+		if h > height {
+			visible.Max.Y = src.Min.Y + src.Dy()*height/h
+			h = height
+		}
+		draw.Draw(c, image.Rect(0, 0, width, h), Resize(subImage(f.Image, visible), width, h), image.Point{}, draw.Src)
+
+		if f.Cursor != nil {
+			x := (f.Cursor.X - src.Min.X) * width / src.Dx()
+			y := (f.Cursor.Y - src.Min.Y) * width / src.Dx()
+			if y < height {
+				drawCursor(c, x, y)
+			}
+		}
+
+		draw.Draw(c, image.Rect(0, height, width, height+bar), image.NewUniform(captionBackground), image.Point{}, draw.Src)
+		for n, line := range wrap(f.Caption, (width-2*pad)/((glyphW+1)*scale), captionLines) {
+			drawText(c, pad, height+pad+n*lineH, line, scale, captionText)
+		}
+		canvases[i] = c
+	}
+	return canvases, nil
+}
+
+// Resize scales an image to w x h, averaging the source pixels each output
+// pixel covers. It is also used to shrink screenshots for the model.
+func Resize(src image.Image, w, h int) *image.RGBA {
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(src.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+	}
+	sb := rgba.Bounds()
+	srcW, srcH := sb.Dx(), sb.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := y * srcH / h
+		y1 := max((y+1)*srcH/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := x * srcW / w
+			x1 := max((x+1)*srcW/w, x0+1)
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := rgba.PixOffset(sb.Min.X+x0, sb.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(rgba.Pix[off])
+					g += uint32(rgba.Pix[off+1])
+					b += uint32(rgba.Pix[off+2])
+					off += 4
+					n++
+				}
+			}
+			off := dst.PixOffset(x, y)
+			dst.Pix[off], dst.Pix[off+1], dst.Pix[off+2], dst.Pix[off+3] = uint8(r/n), uint8(g/n), uint8(b/n), 255
+		}
+	}
+	return dst
+}
+
+// subImage crops img to r when it supports cropping.
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if s, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(r)
+	}
+	return img
+}
+
+// drawCursor marks a click target with a red ring outlined in white.
+func drawCursor(img *image.RGBA, cx, cy int) {
+	const radius = 16
+	for dy := -radius - 3; dy <= radius+3; dy++ {
+		for dx := -radius - 3; dx <= radius+3; dx++ {
+			d2 := dx*dx + dy*dy
+			switch {
+			case d2 <= 9 || (d2 >= (radius-2)*(radius-2) && d2 <= (radius+1)*(radius+1)):
+				img.Set(cx+dx, cy+dy, cursorColor)
+			case d2 <= 16 || (d2 >= (radius-3)*(radius-3) && d2 <= (radius+3)*(radius+3)):
+				img.Set(cx+dx, cy+dy, cursorOutline)
+			}
+		}
+	}
+}
+
+// wrap breaks text into at most maxLines lines of width characters, ending
+// with "..." when it does not fit.
+func wrap(text string, width, maxLines int) []string {
+	if width < 4 {
+		return nil
+	}
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for len(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, word[:width])
+				word = word[width:]
+			}
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := lines[maxLines-1]
+		if len(last) > width-3 {
+			last = last[:width-3]
+		}
+		lines[maxLines-1] = last + "..."
+	}
+	return lines
+}
+
+// quantize maps a frame onto the Plan 9 palette without dithering, which
+// keeps text in screenshots crisp.
+func quantize(img *image.RGBA) *image.Paletted {
+	b := img.Bounds()
+	p := image.NewPaletted(b, palette.Plan9)
+	cache := make(map[uint32]uint8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		off := img.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x++ {
+			key := uint32(img.Pix[off])<<16 | uint32(img.Pix[off+1])<<8 | uint32(img.Pix[off+2])
+			idx, ok := cache[key]
+			if !ok {
+				idx = uint8(p.Palette.Index(color.RGBA{img.Pix[off], img.Pix[off+1], img.Pix[off+2], 255}))
+				cache[key] = idx
+			}
+			p.Pix[p.PixOffset(x, y)] = idx
+			off += 4
+		}
+	}
+	return p
+}
+
+// avi wraps JPEG frames in a RIFF AVI file with one MJPG video stream.
+func avi(frames [][]byte, width, height int, delay time.Duration) []byte {
+	le := binary.LittleEndian
+	u32 := func(buf *bytes.Buffer, vs ...uint32) {
+		for _, v := range vs {
+			binary.Write(buf, le, v)
+		}
+	}
+	chunk := func(buf *bytes.Buffer, id string, data []byte) {
+		buf.WriteString(id)
+		u32(buf, uint32(len(data)))
+		buf.Write(data)
+		if len(data)%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+	list := func(buf *bytes.Buffer, kind string, data []byte) {
+		buf.WriteString("LIST")
+		u32(buf, uint32(4+len(data)))
+		buf.WriteString(kind)
+		buf.Write(data)
+	}
+
+	ms := uint32(delay / time.Millisecond)
+	if ms == 0 {
+		ms = 1
+	}
+	largest := 0
+	for _, f := range frames {
+		largest = max(largest, len(f))
+	}
+	w, h, n := uint32(width), uint32(height), uint32(len(frames))
+
+	var avih bytes.Buffer
+	u32(&avih, ms*1000, 0, 0, 0x10 /* AVIF_HASINDEX */, n, 0, 1, uint32(largest), w, h, 0, 0, 0, 0)
+
+	var strh bytes.Buffer
+	strh.WriteString("vidsMJPG")
+	u32(&strh, 0, 0, 0, ms, 1000, 0, n, uint32(largest), 0xFFFFFFFF, 0)
+	binary.Write(&strh, le, [4]uint16{0, 0, uint16(width), uint16(height)})
+
+	var strf bytes.Buffer
+	u32(&strf, 40, w, h)
+	binary.Write(&strf, le, [2]uint16{1, 24})
+	strf.WriteString("MJPG")
+	u32(&strf, w*h*3, 0, 0, 0, 0)
+
+	var strl bytes.Buffer
+	chunk(&strl, "strh", strh.Bytes())
+	chunk(&strl, "strf", strf.Bytes())
+
+	var hdrl bytes.Buffer
+	chunk(&hdrl, "avih", avih.Bytes())
+	list(&hdrl, "strl", strl.Bytes())
+
+	// idx1 offsets count from the "movi" list type
+	var movi, idx bytes.Buffer
+	for _, f := range frames {
+		u32(&idx, 0, 0x10 /* AVIIF_KEYFRAME */, uint32(4+movi.Len()), uint32(len(f)))
+		copy(idx.Bytes()[idx.Len()-16:], "00dc")
+		chunk(&movi, "00dc", f)
+	}
+
+	var body bytes.Buffer
+	body.WriteString("AVI ")
+	list(&body, "hdrl", hdrl.Bytes())
+	list(&body, "movi", movi.Bytes())
+	chunk(&body, "idx1", idx.Bytes())
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	u32(&out, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}